@@ -0,0 +1,112 @@
+package client
+
+import "net/http"
+
+// HTTPClient holds the configuration shared by every request sent to a host, such as default headers, encoders and decoders.
+// It is immutable once created and safe for concurrent use: each call to NewCall returns an independent request builder.
+type HTTPClient struct {
+	doer                HTTPClientDoer
+	headers             http.Header
+	retryPolicy         *RetryPolicy
+	circuitBreaker      *CircuitBreaker
+	rateLimiter         *RateLimiter
+	bulkhead            *Bulkhead
+	encoders            *EncoderRegistry
+	decoders            *DecoderRegistry
+	compression         *RequestCompression
+	decompressors       []Decompressor
+	maxDecompressedSize int64
+	middlewares         []Middleware
+	successStatusMin    int
+	successStatusMax    int
+	allowedMethods      map[string]struct{}
+	host                string
+	isEncodeURL         bool
+}
+
+// ClientOption configures an HTTPClient when it is created.
+type ClientOption func(*HTTPClient)
+
+// WithDefaultHeaders sets the headers sent with every request. Headers set on a request builder take precedence.
+func WithDefaultHeaders(headers http.Header) ClientOption {
+	return func(c *HTTPClient) {
+		c.headers = headers.Clone()
+	}
+}
+
+// WithRetryPolicy sets the default retry policy for every request. The policy must not be modified afterwards.
+func WithRetryPolicy(policy *RetryPolicy) ClientOption {
+	return func(c *HTTPClient) {
+		c.retryPolicy = policy
+	}
+}
+
+// WithEncodeURL sets whether the query parameters of every request should be encoded.
+func WithEncodeURL(isEncodeURL bool) ClientOption {
+	return func(c *HTTPClient) {
+		c.isEncodeURL = isEncodeURL
+	}
+}
+
+// WithAllowedMethods replaces the methods allowed for every request. It allows extension methods such as PURGE.
+func WithAllowedMethods(methods ...string) ClientOption {
+	return func(c *HTTPClient) {
+		c.allowedMethods = newMethodSet(methods...)
+	}
+}
+
+// NewHTTPClient creates a new HTTPClient with the specified host, HTTP client and options.
+func NewHTTPClient(host string, doer HTTPClientDoer, opts ...ClientOption) *HTTPClient {
+	if doer == nil {
+		panic("You must create client")
+	}
+	if host == "" {
+		panic("empty host")
+	}
+	c := &HTTPClient{
+		doer:                doer,
+		headers:             nil,
+		retryPolicy:         nil,
+		circuitBreaker:      nil,
+		rateLimiter:         nil,
+		bulkhead:            nil,
+		encoders:            nil,
+		decoders:            nil,
+		compression:         nil,
+		decompressors:       nil,
+		maxDecompressedSize: 0,
+		middlewares:         nil,
+		successStatusMin:    defaultSuccessStatusMin,
+		successStatusMax:    defaultSuccessStatusMax,
+		allowedMethods:      nil,
+		host:                host,
+		isEncodeURL:         true,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// NewCall creates a new request builder that inherits the client configuration.
+// The builder is not safe for concurrent use and should be used for a single request.
+func (c *HTTPClient) NewCall() *HTTPClientCall {
+	call := NewHTTPClientCall(c.host, c.doer)
+	call.defaultHeaders = c.headers
+	call.retryPolicy = c.retryPolicy
+	call.circuitBreaker = c.circuitBreaker
+	call.rateLimiter = c.rateLimiter
+	call.bulkhead = c.bulkhead
+	// Cap the shared slice so that Use on the builder never appends into the client middlewares.
+	call.middlewares = c.middlewares[:len(c.middlewares):len(c.middlewares)]
+	call.isEncodeURL = c.isEncodeURL
+	call.successStatusMin = c.successStatusMin
+	call.successStatusMax = c.successStatusMax
+	call.allowedMethods = c.allowedMethods
+	call.encoders = c.encoders
+	call.decoders = c.decoders
+	call.compression = c.compression
+	call.decompressors = c.decompressors
+	call.maxDecompressedSize = c.maxDecompressedSize
+	return call
+}
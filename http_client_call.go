@@ -1,20 +1,33 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
+	"unicode"
 )
 
 // Constants for error messages.
 const (
 	errorEmptyHost        = "empty host"
 	errorEmptyMethod      = "empty method"
+	errorInvalidMethod    = "invalid method"
 	errorMethodNotAllowed = "method not allowed"
 )
 
+// defaultAllowedMethods holds the methods allowed when no allow-list is set: the standard and WebDAV methods.
+var defaultAllowedMethods = newMethodSet(
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+	http.MethodConnect, http.MethodOptions, http.MethodTrace,
+	PROPFIND, PROPPATCH, MKCOL, COPY, MOVE, LOCK, UNLOCK, REPORT,
+)
+
 // HTTPClientDoer is an interface for executing an HTTP request.
 type HTTPClientDoer interface {
 	Do(*http.Request) (*http.Response, error)
@@ -22,15 +35,38 @@ type HTTPClientDoer interface {
 
 // HTTPClientCall encapsulates the configuration and execution of an HTTP request.
 type HTTPClientCall struct {
-	client       HTTPClientDoer
-	method       string
-	host         string
-	path         string
-	params       url.Values
-	headers      http.Header
-	body         any
-	isEncodeURL  bool
-	gzipCompress bool
+	client              HTTPClientDoer
+	method              string
+	host                string
+	path                string
+	route               string
+	params              url.Values
+	headers             http.Header
+	defaultHeaders      http.Header
+	body                any
+	encoder             RequestEncoder
+	encoders            *EncoderRegistry
+	decoder             ResponseDecoder
+	decoders            *DecoderRegistry
+	getBody             func() (io.ReadCloser, error)
+	onUploadProgress    func(sent, total int64)
+	eventStreamRetry    time.Duration
+	compression         *RequestCompression
+	decompressors       []Decompressor
+	maxDecompressedSize int64
+	retryPolicy         *RetryPolicy
+	circuitBreaker      *CircuitBreaker
+	rateLimiter         *RateLimiter
+	bulkhead            *Bulkhead
+	middlewares         []Middleware
+	errorBody           any
+	successStatusMin    int
+	successStatusMax    int
+	isEncodeURL         bool
+	allowedMethods      map[string]struct{}
+	gzipCompress        bool
+	skipMiddlewares     bool
+	keepRawBody         bool
 }
 
 // NewHTTPClientCall creates a new HTTPClientCall with the specified host and HTTP client.
@@ -42,15 +78,38 @@ func NewHTTPClientCall(host string, client HTTPClientDoer) *HTTPClientCall {
 		panic("empty host")
 	}
 	return &HTTPClientCall{
-		client:       client,
-		host:         host,
-		path:         "",
-		params:       nil,
-		isEncodeURL:  true,
-		method:       "",
-		headers:      nil,
-		body:         nil,
-		gzipCompress: false,
+		client:              client,
+		host:                host,
+		path:                "",
+		route:               "",
+		params:              nil,
+		isEncodeURL:         true,
+		method:              "",
+		headers:             nil,
+		defaultHeaders:      nil,
+		body:                nil,
+		encoder:             nil,
+		encoders:            nil,
+		decoder:             nil,
+		decoders:            nil,
+		getBody:             nil,
+		onUploadProgress:    nil,
+		eventStreamRetry:    0,
+		compression:         nil,
+		decompressors:       nil,
+		maxDecompressedSize: 0,
+		retryPolicy:         nil,
+		circuitBreaker:      nil,
+		rateLimiter:         nil,
+		bulkhead:            nil,
+		middlewares:         nil,
+		errorBody:           nil,
+		successStatusMin:    defaultSuccessStatusMin,
+		successStatusMax:    defaultSuccessStatusMax,
+		allowedMethods:      nil,
+		gzipCompress:        false,
+		skipMiddlewares:     false,
+		keepRawBody:         false,
 	}
 }
 
@@ -84,7 +143,8 @@ func (r *HTTPClientCall) Headers(headers http.Header) *HTTPClientCall {
 	return r
 }
 
-// Body sets the body for the HTTP request.
+// Body sets the body for the HTTP request. io.Reader and *MultipartForm bodies are streamed, any other value is
+// encoded with the encoder selected for the request.
 func (r *HTTPClientCall) Body(body any) *HTTPClientCall {
 	r.body = body
 	return r
@@ -96,8 +156,33 @@ func (r *HTTPClientCall) UseGzipCompress(gzipCompress bool) *HTTPClientCall {
 	return r
 }
 
+// AllowedMethods replaces the methods allowed for the HTTP request. It allows extension methods such as PURGE.
+func (r *HTTPClientCall) AllowedMethods(methods ...string) *HTTPClientCall {
+	r.allowedMethods = newMethodSet(methods...)
+	return r
+}
+
 // Do executes the HTTP request with the configured settings.
 func (r *HTTPClientCall) Do(ctx context.Context) (*http.Response, error) {
+	resp, _, err := r.do(ctx)
+	return resp, err
+}
+
+// do executes the HTTP request and returns the response together with the number of attempts made.
+func (r *HTTPClientCall) do(ctx context.Context) (*http.Response, int, error) {
+	req, err := r.newRequest(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp, attempts, err := r.send(ctx, req)
+	r.params = nil
+	r.body = nil
+	return resp, attempts, err
+}
+
+// newRequest validates the configuration and builds the HTTP request with its body and headers.
+func (r *HTTPClientCall) newRequest(ctx context.Context) (*http.Request, error) {
 	if r.host == "" {
 		return nil, errors.New(errorEmptyHost)
 	}
@@ -105,6 +190,9 @@ func (r *HTTPClientCall) Do(ctx context.Context) (*http.Response, error) {
 	if err := r.validateHTTPMethod(); err != nil {
 		return nil, err
 	}
+	if r.route != "" {
+		ctx = context.WithValue(ctx, routeContextKey{}, r.route)
+	}
 	fullURL := r.constructURL()
 	req, err := newClientRequest(ctx, r.method, fullURL)
 	if err != nil {
@@ -115,21 +203,49 @@ func (r *HTTPClientCall) Do(ctx context.Context) (*http.Response, error) {
 		return nil, err
 	}
 	r.setHeaders(req)
+	r.setAcceptEncoding(req)
+	return req, nil
+}
 
-	resp, err := r.client.Do(req)
-	r.params = nil
-	r.body = nil
-	return resp, err
+// send sends the request with retries and decodes the content encoding of the response.
+func (r *HTTPClientCall) send(ctx context.Context, req *http.Request) (*http.Response, int, error) {
+	resp, attempts, err := r.doWithRetry(ctx, req)
+	if err != nil {
+		return resp, attempts, err
+	}
+	if err = r.decompressResponse(resp, r.decompressors); err != nil {
+		_ = resp.Body.Close()
+		return nil, attempts, err
+	}
+	return resp, attempts, nil
 }
 
-// HTTPClientCallResponse encapsulates the response status code from an HTTP request.
+// HTTPClientCallResponse encapsulates the response metadata from an HTTP request.
 type HTTPClientCallResponse struct {
-	StatusCode int `json:"status_code"`
+	Header     http.Header   `json:"header"`
+	URL        string        `json:"url"`
+	Proto      string        `json:"proto"`
+	RawBody    []byte        `json:"raw_body,omitempty"`
+	StatusCode int           `json:"status_code"`
+	Attempts   int           `json:"attempts"`
+	Duration   time.Duration `json:"duration"`
+	RetryAfter time.Duration `json:"retry_after"`
+}
+
+// KeepRawBody sets whether DoWithUnmarshal should keep a copy of the response body in HTTPClientCallResponse.RawBody.
+func (r *HTTPClientCall) KeepRawBody(keepRawBody bool) *HTTPClientCall {
+	r.keepRawBody = keepRawBody
+	return r
 }
 
 // DoWithUnmarshal executes the HTTP request and unmarshals the response body into the provided interface.
+// Responses with a status code outside the success range are returned as an *HTTPError.
+// The body of HEAD and 204 No Content responses is not decoded. Responses of unknown content types are decoded by
+// BinaryResponseDecoder into a *[]byte, an io.Writer or a FilePath destination.
 func (r *HTTPClientCall) DoWithUnmarshal(ctx context.Context, responseBody any) (*HTTPClientCallResponse, error) {
-	resp, err := r.Do(ctx)
+	start := time.Now()
+	requestURL := r.constructURL()
+	resp, attempts, err := r.do(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -137,32 +253,105 @@ func (r *HTTPClientCall) DoWithUnmarshal(ctx context.Context, responseBody any)
 		_ = resp.Body.Close()
 	}()
 
+	httpClientCallResponse := newHTTPClientCallResponse(resp, requestURL, attempts)
+	// The duration covers the whole call, including reading and decoding the response body.
+	defer func() {
+		httpClientCallResponse.Duration = time.Since(start)
+	}()
+	if !r.isSuccessStatus(resp.StatusCode) {
+		return httpClientCallResponse, r.newHTTPError(resp)
+	}
+
+	if r.method == http.MethodHead || resp.StatusCode == http.StatusNoContent {
+		return httpClientCallResponse, nil
+	}
+	if err = r.decodeResponse(resp, responseBody, httpClientCallResponse); err != nil {
+		return nil, err
+	}
+	return httpClientCallResponse, nil
+}
+
+// decodeResponse decodes the content encoding of the response body, then the body into responseBody with the
+// decoder of its content type, keeping the raw body in httpClientCallResponse when requested.
+func (r *HTTPClientCall) decodeResponse(resp *http.Response, responseBody any, httpClientCallResponse *HTTPClientCallResponse) error {
+	if err := r.decodeResponseContent(resp); err != nil {
+		return err
+	}
 	contentType := resp.Header.Get("Content-Type")
-	decoder := selectDecoder(contentType)
+	decoder := r.decoder
+	if decoder == nil {
+		decoder = r.lookupDecoder(contentType)
+	}
+	if decoder == nil && isBinaryDestination(responseBody) {
+		decoder = &BinaryResponseDecoder{}
+	}
 	if decoder == nil {
-		return nil, fmt.Errorf("unsupported content type: %s", contentType)
+		return fmt.Errorf("unsupported content type: %s", contentType)
 	}
 
-	err = decoder.Decode(resp.Body, responseBody)
-	if err != nil {
-		return nil, err
+	var body io.Reader = resp.Body
+	if r.keepRawBody {
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		httpClientCallResponse.RawBody = data
+		body = bytes.NewReader(data)
 	}
+	return decoder.Decode(body, responseBody)
+}
 
-	httpClientCallResponse := &HTTPClientCallResponse{
+// newHTTPClientCallResponse builds the response metadata. requestURL is used when the response has no request.
+func newHTTPClientCallResponse(resp *http.Response, requestURL string, attempts int) *HTTPClientCallResponse {
+	if resp.Request != nil && resp.Request.URL != nil {
+		requestURL = resp.Request.URL.String()
+	}
+	retryAfter, _ := ParseRetryAfter(resp.Header.Get(HeaderRetryAfter))
+	return &HTTPClientCallResponse{
+		Header:     resp.Header,
+		URL:        requestURL,
+		Proto:      resp.Proto,
+		RawBody:    nil,
 		StatusCode: resp.StatusCode,
+		Attempts:   attempts,
+		Duration:   0,
+		RetryAfter: retryAfter,
 	}
-	return httpClientCallResponse, nil
 }
 
-// validateHTTPMethod checks if the HTTP method is valid and allowed.
+// validateHTTPMethod checks if the HTTP method is a valid token and is allowed.
 func (r *HTTPClientCall) validateHTTPMethod() error {
 	if r.method == "" {
 		return errors.New(errorEmptyMethod)
 	}
-	switch r.method {
-	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch:
-		return nil
-	default:
+	if !isToken(r.method) {
+		return errors.New(errorInvalidMethod)
+	}
+	allowedMethods := r.allowedMethods
+	if allowedMethods == nil {
+		allowedMethods = defaultAllowedMethods
+	}
+	if _, ok := allowedMethods[r.method]; !ok {
 		return errors.New(errorMethodNotAllowed)
 	}
+	return nil
+}
+
+// newMethodSet builds a set from the given methods.
+func newMethodSet(methods ...string) map[string]struct{} {
+	set := make(map[string]struct{}, len(methods))
+	for _, method := range methods {
+		set[method] = struct{}{}
+	}
+	return set
+}
+
+// isToken reports whether s is a valid HTTP token as defined by RFC 9110.
+func isToken(s string) bool {
+	for _, c := range s {
+		if c > unicode.MaxASCII || !(unicode.IsLetter(c) || unicode.IsDigit(c) || strings.ContainsRune("!#$%&'*+-.^_`|~", c)) {
+			return false
+		}
+	}
+	return s != ""
 }
@@ -0,0 +1,172 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrBulkheadFull is returned when a bulkhead rejects a request because its queue is full.
+	ErrBulkheadFull = errors.New("bulkhead is full")
+	// ErrBulkheadTimeout is returned when a request waited in the bulkhead queue longer than the queue timeout.
+	ErrBulkheadTimeout = errors.New("bulkhead queue timeout")
+)
+
+// Bulkhead caps the number of requests in flight per key, so that a slow downstream cannot exhaust goroutines
+// and connections. Requests over the limit wait in a bounded queue. A request holds its slot until its response
+// body is read to the end or closed. It is safe for concurrent use; its settings must not be modified once it is
+// in use.
+type Bulkhead struct {
+	// Key returns the key of the compartment used for a request, HostKey when nil.
+	Key KeyFunc
+	// MaxConcurrent is the maximum number of requests in flight per key, at least 1.
+	MaxConcurrent int
+	// MaxQueue is the maximum number of requests waiting for a slot per key. Zero rejects requests immediately.
+	MaxQueue int
+	// QueueTimeout is the maximum time a request waits for a slot. Zero waits until the request context is done.
+	QueueTimeout time.Duration
+	compartments map[string]*compartment
+	mu           sync.Mutex
+}
+
+// compartment holds the slots and the queue length of a key.
+type compartment struct {
+	slots  chan struct{}
+	queued int
+}
+
+// NewBulkhead creates a Bulkhead keyed by host with the given maximum of concurrent and queued requests.
+func NewBulkhead(maxConcurrent, maxQueue int) *Bulkhead {
+	return &Bulkhead{
+		Key:           HostKey,
+		MaxConcurrent: maxConcurrent,
+		MaxQueue:      maxQueue,
+		QueueTimeout:  0,
+		compartments:  make(map[string]*compartment),
+		mu:            sync.Mutex{},
+	}
+}
+
+// Bulkhead sets the bulkhead of the HTTP request. It is checked before every attempt, inside the rate limiter
+// and outside of the middlewares. A nil bulkhead disables it.
+func (r *HTTPClientCall) Bulkhead(bulkhead *Bulkhead) *HTTPClientCall {
+	r.bulkhead = bulkhead
+	return r
+}
+
+// WithBulkhead sets the bulkhead shared by every request sent by the client.
+func WithBulkhead(bulkhead *Bulkhead) ClientOption {
+	return func(c *HTTPClient) {
+		c.bulkhead = bulkhead
+	}
+}
+
+// wrap returns a doer that acquires a slot before sending each request and releases it with the response body.
+func (b *Bulkhead) wrap(next HTTPClientDoer) HTTPClientDoer {
+	return HTTPClientDoerFunc(func(req *http.Request) (*http.Response, error) {
+		key := b.key(req)
+		release, err := b.acquire(req.Context(), key)
+		if err != nil {
+			closeRequestBody(req)
+			return nil, err
+		}
+		resp, err := next.Do(req)
+		if err != nil || resp == nil || resp.Body == nil {
+			release()
+			return resp, err
+		}
+		resp.Body = &releasingBody{ReadCloser: resp.Body, release: release, once: sync.Once{}}
+		return resp, nil
+	})
+}
+
+// acquire takes a slot of the key compartment, waiting in its queue when every slot is in use, and returns the
+// function that releases it.
+func (b *Bulkhead) acquire(ctx context.Context, key string) (func(), error) {
+	c := b.compartment(key)
+	release := func() { <-c.slots }
+	select {
+	case c.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	b.mu.Lock()
+	if c.queued >= b.MaxQueue {
+		b.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrBulkheadFull, key)
+	}
+	c.queued++
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		c.queued--
+		b.mu.Unlock()
+	}()
+
+	var timeout <-chan time.Time
+	if b.QueueTimeout > 0 {
+		timer := time.NewTimer(b.QueueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case c.slots <- struct{}{}:
+		return release, nil
+	case <-timeout:
+		return nil, fmt.Errorf("%w: %s", ErrBulkheadTimeout, key)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// compartment returns the compartment of key.
+func (b *Bulkhead) compartment(key string) *compartment {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.compartments == nil {
+		b.compartments = make(map[string]*compartment)
+	}
+	c, ok := b.compartments[key]
+	if !ok {
+		c = &compartment{slots: make(chan struct{}, max(b.MaxConcurrent, 1)), queued: 0}
+		b.compartments[key] = c
+	}
+	return c
+}
+
+// key returns the key of the compartment used for the request.
+func (b *Bulkhead) key(req *http.Request) string {
+	if b.Key == nil {
+		return HostKey(req)
+	}
+	return b.Key(req)
+}
+
+// releasingBody releases the bulkhead slot of a request once its body is read to the end or closed.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+// Read reads from the body and releases the slot at the end of the body.
+func (b *releasingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if errors.Is(err, io.EOF) {
+		b.once.Do(b.release)
+	}
+	return n, err
+}
+
+// Close closes the body and releases the slot.
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
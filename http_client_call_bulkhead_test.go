@@ -0,0 +1,218 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// BlockingHTTPClient blocks every request until Release is closed and tracks the requests in flight.
+type BlockingHTTPClient struct {
+	Release  chan struct{}
+	Started  chan struct{}
+	InFlight atomic.Int32
+	MaxSeen  atomic.Int32
+}
+
+func NewBlockingHTTPClient() *BlockingHTTPClient {
+	return &BlockingHTTPClient{Release: make(chan struct{}), Started: make(chan struct{}, 100)}
+}
+
+func (c *BlockingHTTPClient) Do(_ *http.Request) (*http.Response, error) {
+	inFlight := c.InFlight.Add(1)
+	for {
+		maxSeen := c.MaxSeen.Load()
+		if inFlight <= maxSeen || c.MaxSeen.CompareAndSwap(maxSeen, inFlight) {
+			break
+		}
+	}
+	c.Started <- struct{}{}
+	<-c.Release
+	c.InFlight.Add(-1)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{},
+		Body:       io.NopCloser(bytes.NewBufferString("ok")),
+	}, nil
+}
+
+type HTTPClientCallBulkheadSuite struct {
+	suite.Suite
+}
+
+func (suite *HTTPClientCallBulkheadSuite) call(ctx context.Context, doer HTTPClientDoer, bulkhead *Bulkhead) error {
+	return suite.send(ctx, NewHTTPClientCall("http://example.com", doer).Bulkhead(bulkhead))
+}
+
+func (suite *HTTPClientCallBulkheadSuite) send(ctx context.Context, call *HTTPClientCall) error {
+	resp, err := call.Method(http.MethodGet).Do(ctx)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (suite *HTTPClientCallBulkheadSuite) TestBulkhead() {
+	suite.Run("caps the requests in flight and queues the others", func() {
+		doer := NewBlockingHTTPClient()
+		bulkhead := NewBulkhead(2, 10)
+		var wg sync.WaitGroup
+		errs := make(chan error, 5)
+		for range 5 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- suite.call(context.Background(), doer, bulkhead)
+			}()
+		}
+		<-doer.Started
+		<-doer.Started
+		close(doer.Release)
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			suite.NoError(err)
+		}
+		suite.Equal(int32(2), doer.MaxSeen.Load())
+	})
+
+	suite.Run("rejects requests when the queue is full", func() {
+		doer := NewBlockingHTTPClient()
+		bulkhead := NewBulkhead(1, 0)
+		done := make(chan error)
+		go func() { done <- suite.call(context.Background(), doer, bulkhead) }()
+		<-doer.Started
+		err := suite.call(context.Background(), doer, bulkhead)
+		suite.ErrorIs(err, ErrBulkheadFull)
+		suite.EqualError(err, "bulkhead is full: example.com")
+		close(doer.Release)
+		suite.NoError(<-done)
+	})
+
+	suite.Run("closes the body of rejected requests", func() {
+		doer := NewBlockingHTTPClient()
+		bulkhead := NewBulkhead(1, 0)
+		done := make(chan error)
+		go func() { done <- suite.call(context.Background(), doer, bulkhead) }()
+		<-doer.Started
+		body := &CloseCountingBody{Reader: strings.NewReader("payload")}
+		err := suite.send(context.Background(), NewHTTPClientCall("http://example.com", doer).Body(body).Bulkhead(bulkhead))
+		suite.ErrorIs(err, ErrBulkheadFull)
+		suite.Equal(int32(1), body.Closes.Load())
+		close(doer.Release)
+		suite.NoError(<-done)
+	})
+
+	suite.Run("times out requests waiting in the queue", func() {
+		doer := NewBlockingHTTPClient()
+		bulkhead := NewBulkhead(1, 1)
+		bulkhead.QueueTimeout = 20 * time.Millisecond
+		done := make(chan error)
+		go func() { done <- suite.call(context.Background(), doer, bulkhead) }()
+		<-doer.Started
+		suite.ErrorIs(suite.call(context.Background(), doer, bulkhead), ErrBulkheadTimeout)
+		close(doer.Release)
+		suite.NoError(<-done)
+	})
+
+	suite.Run("stops waiting when the context is cancelled", func() {
+		doer := NewBlockingHTTPClient()
+		bulkhead := NewBulkhead(1, 1)
+		done := make(chan error)
+		go func() { done <- suite.call(context.Background(), doer, bulkhead) }()
+		<-doer.Started
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+		suite.ErrorIs(suite.call(ctx, doer, bulkhead), context.Canceled)
+		close(doer.Release)
+		suite.NoError(<-done)
+	})
+
+	suite.Run("holds the slot until the response body is closed", func() {
+		bulkhead := NewBulkhead(1, 0)
+		doer := &MockHTTPClient{Response: newStatusResponse(http.StatusOK)}
+		resp, err := NewHTTPClientCall("http://example.com", doer).Method(http.MethodGet).Bulkhead(bulkhead).
+			Do(context.Background())
+		require.NoError(suite.T(), err)
+		_, err = NewHTTPClientCall("http://example.com", doer).Method(http.MethodGet).Bulkhead(bulkhead).
+			Do(context.Background())
+		suite.ErrorIs(err, ErrBulkheadFull)
+		suite.NoError(resp.Body.Close())
+		suite.NoError(resp.Body.Close())
+		suite.NoError(suite.call(context.Background(), &RecordingHTTPClient{}, bulkhead))
+	})
+
+	suite.Run("releases the slot at the end of the response body", func() {
+		bulkhead := NewBulkhead(1, 0)
+		resp, err := NewHTTPClientCall("http://example.com", &RecordingHTTPClient{}).Method(http.MethodGet).
+			Bulkhead(bulkhead).Do(context.Background())
+		require.NoError(suite.T(), err)
+		_, err = io.ReadAll(resp.Body)
+		suite.NoError(err)
+		suite.NoError(suite.call(context.Background(), &RecordingHTTPClient{}, bulkhead))
+	})
+
+	suite.Run("keeps a compartment per key", func() {
+		doer := NewBlockingHTTPClient()
+		bulkhead := NewBulkhead(1, 0)
+		c := NewHTTPClient("http://a.example.com", doer, WithBulkhead(bulkhead))
+		done := make(chan error)
+		go func() {
+			done <- suite.send(context.Background(), c.NewCall())
+		}()
+		<-doer.Started
+		go func() { done <- suite.call(context.Background(), doer, bulkhead) }()
+		<-doer.Started
+		close(doer.Release)
+		suite.NoError(<-done)
+		suite.NoError(<-done)
+	})
+
+	suite.Run("is not retried by the default retry policy", func() {
+		doer := NewBlockingHTTPClient()
+		bulkhead := NewBulkhead(1, 0)
+		done := make(chan error)
+		go func() { done <- suite.call(context.Background(), doer, bulkhead) }()
+		<-doer.Started
+		_, attempts, err := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodGet).
+			Bulkhead(bulkhead).
+			Retry(NewRetryPolicy()).
+			do(context.Background())
+		suite.ErrorIs(err, ErrBulkheadFull)
+		suite.Equal(1, attempts)
+		close(doer.Release)
+		suite.NoError(<-done)
+	})
+
+	suite.Run("is not counted as a failure by the circuit breaker", func() {
+		doer := NewBlockingHTTPClient()
+		bulkhead := NewBulkhead(1, 0)
+		breaker := NewCircuitBreaker()
+		breaker.ConsecutiveFailures = 3
+		c := NewHTTPClient("http://example.com", doer, WithCircuitBreaker(breaker), WithBulkhead(bulkhead))
+		done := make(chan error)
+		go func() { done <- suite.send(context.Background(), c.NewCall()) }()
+		<-doer.Started
+		for range 3 {
+			suite.ErrorIs(suite.send(context.Background(), c.NewCall()), ErrBulkheadFull)
+		}
+		suite.Equal(CircuitClosed, breaker.State("example.com"))
+		close(doer.Release)
+		suite.NoError(<-done)
+		suite.NoError(suite.send(context.Background(), c.NewCall()))
+	})
+}
+
+func TestHTTPClientCallBulkheadSuite(t *testing.T) {
+	suite.Run(t, new(HTTPClientCallBulkheadSuite))
+}
@@ -0,0 +1,407 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Default values used by NewCircuitBreaker and by CircuitBreaker fields left at their zero value.
+const (
+	defaultCircuitOpenDuration        = 30 * time.Second
+	defaultCircuitHalfOpenMaxRequests = 1
+)
+
+// ErrCircuitOpen is returned, wrapped in a *CircuitOpenError, when a circuit breaker rejects a request.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of a circuit.
+type CircuitState int
+
+const (
+	// CircuitClosed lets requests through and counts their failures.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects requests until the open duration has elapsed.
+	CircuitOpen
+	// CircuitHalfOpen lets a limited number of trial requests through to decide whether to close the circuit.
+	CircuitHalfOpen
+)
+
+// String returns the name of the state.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("CircuitState(%d)", int(s))
+	}
+}
+
+// CircuitOpenError is returned without calling the HTTP client when the circuit of a request is open, or when
+// the trial requests of a half-open circuit are all in flight. It matches ErrCircuitOpen with errors.Is.
+type CircuitOpenError struct {
+	// Key identifies the circuit.
+	Key string
+	// State is the state of the circuit when the request was rejected.
+	State CircuitState
+	// RetryAfter is the time left before the open circuit lets trial requests through.
+	RetryAfter time.Duration
+}
+
+// Error returns the error message.
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s: %s (%s)", ErrCircuitOpen, e.Key, e.State)
+}
+
+// Is reports whether target is ErrCircuitOpen.
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// KeyFunc returns the key of the circuit used for a request.
+type KeyFunc func(req *http.Request) string
+
+// HostKey keys circuits by the host of the request URL.
+func HostKey(req *http.Request) string {
+	return req.URL.Host
+}
+
+// RouteKey keys circuits by the host and the route template set with Route, falling back to the host.
+func RouteKey(req *http.Request) string {
+	if route := RouteTemplate(req.Context()); route != "" {
+		return req.URL.Host + route
+	}
+	return req.URL.Host
+}
+
+// routeContextKey is the context key of the route template.
+type routeContextKey struct{}
+
+// RouteTemplate returns the route template of the request context, or an empty string if there is none.
+func RouteTemplate(ctx context.Context) string {
+	route, _ := ctx.Value(routeContextKey{}).(string)
+	return route
+}
+
+// Route sets the route template of the HTTP request, such as /users/{id}. It keys circuits with RouteKey and is
+// available to middlewares through RouteTemplate.
+func (r *HTTPClientCall) Route(template string) *HTTPClientCall {
+	r.route = template
+	return r
+}
+
+// DefaultIsFailure counts transport errors and 5xx responses as failures. Cancellations of the request context and
+// bulkhead rejections are not failures, since the downstream did not answer.
+func DefaultIsFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		return !inconclusive(err)
+	}
+	return resp.StatusCode >= http.StatusInternalServerError
+}
+
+// inconclusive reports whether a request ended without an answer of the downstream, because its context was done
+// or a bulkhead rejected it.
+func inconclusive(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, ErrBulkheadFull) || errors.Is(err, ErrBulkheadTimeout)
+}
+
+// CircuitBreaker stops sending requests to a failing downstream. Each key has its own circuit, which opens when
+// the failures reach a threshold, rejects requests with ErrCircuitOpen for OpenDuration, then lets trial requests
+// through in the half-open state and closes again once they succeed. It is safe for concurrent use; its settings
+// must not be modified once it is in use.
+type CircuitBreaker struct {
+	// Key returns the key of the circuit used for a request, HostKey when nil.
+	Key KeyFunc
+	// IsFailure reports whether the outcome of a request is a failure, DefaultIsFailure when nil.
+	IsFailure func(resp *http.Response, err error) bool
+	// OnStateChange is called after a circuit changed state, outside of the breaker lock.
+	OnStateChange func(key string, from, to CircuitState)
+	// FailureRatio opens the circuit when the ratio of failed requests reaches it. Zero disables it.
+	FailureRatio float64
+	// ConsecutiveFailures opens the circuit after this number of consecutive failures. Zero disables it.
+	ConsecutiveFailures int
+	// MinRequests is the number of requests needed before FailureRatio is evaluated.
+	MinRequests int
+	// Interval clears the counts of closed circuits periodically. Zero keeps them until the circuit opens.
+	Interval time.Duration
+	// OpenDuration is how long an open circuit rejects requests before turning half-open.
+	OpenDuration time.Duration
+	// HalfOpenMaxRequests is the number of trial requests that must succeed to close a half-open circuit.
+	HalfOpenMaxRequests int
+	circuits            map[string]*circuit
+	mu                  sync.Mutex
+}
+
+// circuit holds the state and the counts of a key.
+type circuit struct {
+	expiry           time.Time
+	state            CircuitState
+	generation       uint64
+	requests         int
+	failures         int
+	consecutive      int
+	halfOpenRequests int
+	halfOpenSuccess  int
+}
+
+// circuitStateChange records a state change to notify once the breaker lock is released.
+type circuitStateChange struct {
+	key      string
+	from, to CircuitState
+}
+
+// NewCircuitBreaker creates a CircuitBreaker keyed by host that opens after 5 consecutive failures or when half of
+// at least 10 requests fail, and stays open for 30 seconds.
+func NewCircuitBreaker() *CircuitBreaker {
+	return &CircuitBreaker{
+		Key:                 HostKey,
+		IsFailure:           DefaultIsFailure,
+		OnStateChange:       nil,
+		FailureRatio:        0.5,
+		ConsecutiveFailures: 5,
+		MinRequests:         10,
+		Interval:            0,
+		OpenDuration:        defaultCircuitOpenDuration,
+		HalfOpenMaxRequests: defaultCircuitHalfOpenMaxRequests,
+		circuits:            make(map[string]*circuit),
+		mu:                  sync.Mutex{},
+	}
+}
+
+// CircuitBreaker sets the circuit breaker of the HTTP request. It wraps the HTTP client outside of the middlewares
+// and is checked before every attempt. A nil breaker disables it.
+func (r *HTTPClientCall) CircuitBreaker(breaker *CircuitBreaker) *HTTPClientCall {
+	r.circuitBreaker = breaker
+	return r
+}
+
+// WithCircuitBreaker sets the circuit breaker shared by every request sent by the client.
+func WithCircuitBreaker(breaker *CircuitBreaker) ClientOption {
+	return func(c *HTTPClient) {
+		c.circuitBreaker = breaker
+	}
+}
+
+// State returns the current state of the circuit of the given key.
+func (b *CircuitBreaker) State(key string) CircuitState {
+	b.mu.Lock()
+	c, changes := b.circuit(key, time.Now())
+	state := c.state
+	b.mu.Unlock()
+	b.notify(changes)
+	return state
+}
+
+// wrap returns a doer that rejects the requests of open circuits and records the outcome of the others. Requests
+// that end without an answer of the downstream are neither successes nor failures.
+func (b *CircuitBreaker) wrap(next HTTPClientDoer) HTTPClientDoer {
+	return HTTPClientDoerFunc(func(req *http.Request) (*http.Response, error) {
+		key := b.key(req)
+		generation, err := b.allow(key)
+		if err != nil {
+			closeRequestBody(req)
+			return nil, err
+		}
+		resp, err := next.Do(req)
+		if inconclusive(err) {
+			b.release(key, generation)
+		} else {
+			b.record(key, generation, b.isFailure(resp, err))
+		}
+		return resp, err
+	})
+}
+
+// closeRequestBody closes the body of a request rejected before being sent, as HTTPClientDoer implementations must
+// do even on errors, so that streamed bodies release their goroutines and files.
+func closeRequestBody(req *http.Request) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+}
+
+// allow reserves a request on the circuit of key and returns the generation of the circuit state.
+func (b *CircuitBreaker) allow(key string) (uint64, error) {
+	now := time.Now()
+	b.mu.Lock()
+	c, changes := b.circuit(key, now)
+	var err error
+	switch c.state {
+	case CircuitOpen:
+		err = &CircuitOpenError{Key: key, State: CircuitOpen, RetryAfter: c.expiry.Sub(now)}
+	case CircuitHalfOpen:
+		if c.halfOpenRequests >= b.halfOpenMaxRequests() {
+			err = &CircuitOpenError{Key: key, State: CircuitHalfOpen, RetryAfter: 0}
+		} else {
+			c.halfOpenRequests++
+		}
+	default:
+		c.requests++
+	}
+	generation := c.generation
+	b.mu.Unlock()
+	b.notify(changes)
+	return generation, err
+}
+
+// record counts the outcome of a request allowed in the given generation and changes the circuit state when
+// a threshold is reached. Outcomes of requests allowed before the last state change are ignored.
+func (b *CircuitBreaker) record(key string, generation uint64, failure bool) {
+	now := time.Now()
+	b.mu.Lock()
+	c, changes := b.circuit(key, now)
+	if c.generation == generation {
+		switch {
+		case c.state == CircuitClosed && failure:
+			c.failures++
+			c.consecutive++
+			if b.tripped(c) {
+				changes = append(changes, b.setState(key, c, CircuitOpen, now))
+			}
+		case c.state == CircuitClosed:
+			c.consecutive = 0
+		case c.state == CircuitHalfOpen && failure:
+			changes = append(changes, b.setState(key, c, CircuitOpen, now))
+		case c.state == CircuitHalfOpen:
+			c.halfOpenSuccess++
+			if c.halfOpenSuccess >= b.halfOpenMaxRequests() {
+				changes = append(changes, b.setState(key, c, CircuitClosed, now))
+			}
+		}
+	}
+	b.mu.Unlock()
+	b.notify(changes)
+}
+
+// release gives back the request reserved by allow in the given generation without counting an outcome, freeing
+// the slot of a half-open trial request.
+func (b *CircuitBreaker) release(key string, generation uint64) {
+	b.mu.Lock()
+	c, changes := b.circuit(key, time.Now())
+	if c.generation == generation {
+		switch c.state {
+		case CircuitClosed:
+			c.requests = max(c.requests-1, 0)
+		case CircuitHalfOpen:
+			c.halfOpenRequests = max(c.halfOpenRequests-1, 0)
+		}
+	}
+	b.mu.Unlock()
+	b.notify(changes)
+}
+
+// circuit returns the circuit of key, turning it half-open once its open duration has elapsed and clearing
+// the counts of a closed circuit at every interval. It must be called with the lock held.
+func (b *CircuitBreaker) circuit(key string, now time.Time) (*circuit, []circuitStateChange) {
+	if b.circuits == nil {
+		b.circuits = make(map[string]*circuit)
+	}
+	c, ok := b.circuits[key]
+	if !ok {
+		c = &circuit{
+			expiry:           time.Time{},
+			state:            CircuitClosed,
+			generation:       0,
+			requests:         0,
+			failures:         0,
+			consecutive:      0,
+			halfOpenRequests: 0,
+			halfOpenSuccess:  0,
+		}
+		b.resetCounts(c, now)
+		b.circuits[key] = c
+	}
+	var changes []circuitStateChange
+	switch {
+	case c.state == CircuitOpen && !now.Before(c.expiry):
+		changes = append(changes, b.setState(key, c, CircuitHalfOpen, now))
+	case c.state == CircuitClosed && b.Interval > 0 && !now.Before(c.expiry):
+		b.resetCounts(c, now)
+	}
+	return c, changes
+}
+
+// setState moves the circuit to a new state with cleared counts. It must be called with the lock held.
+func (b *CircuitBreaker) setState(key string, c *circuit, state CircuitState, now time.Time) circuitStateChange {
+	change := circuitStateChange{key: key, from: c.state, to: state}
+	c.state = state
+	c.generation++
+	b.resetCounts(c, now)
+	return change
+}
+
+// resetCounts clears the counts of the circuit and sets the expiry of its current state.
+func (b *CircuitBreaker) resetCounts(c *circuit, now time.Time) {
+	c.requests = 0
+	c.failures = 0
+	c.consecutive = 0
+	c.halfOpenRequests = 0
+	c.halfOpenSuccess = 0
+	switch {
+	case c.state == CircuitOpen:
+		c.expiry = now.Add(b.openDuration())
+	case c.state == CircuitClosed && b.Interval > 0:
+		c.expiry = now.Add(b.Interval)
+	default:
+		c.expiry = time.Time{}
+	}
+}
+
+// tripped reports whether the counts of a closed circuit reach a threshold.
+func (b *CircuitBreaker) tripped(c *circuit) bool {
+	if b.ConsecutiveFailures > 0 && c.consecutive >= b.ConsecutiveFailures {
+		return true
+	}
+	return b.FailureRatio > 0 && c.requests >= b.MinRequests &&
+		float64(c.failures)/float64(c.requests) >= b.FailureRatio
+}
+
+// notify calls OnStateChange for every state change.
+func (b *CircuitBreaker) notify(changes []circuitStateChange) {
+	if b.OnStateChange == nil {
+		return
+	}
+	for _, change := range changes {
+		b.OnStateChange(change.key, change.from, change.to)
+	}
+}
+
+// key returns the key of the circuit used for the request.
+func (b *CircuitBreaker) key(req *http.Request) string {
+	if b.Key == nil {
+		return HostKey(req)
+	}
+	return b.Key(req)
+}
+
+// isFailure reports whether the outcome of a request is a failure.
+func (b *CircuitBreaker) isFailure(resp *http.Response, err error) bool {
+	if b.IsFailure == nil {
+		return DefaultIsFailure(resp, err)
+	}
+	return b.IsFailure(resp, err)
+}
+
+// openDuration returns how long an open circuit rejects requests.
+func (b *CircuitBreaker) openDuration() time.Duration {
+	if b.OpenDuration <= 0 {
+		return defaultCircuitOpenDuration
+	}
+	return b.OpenDuration
+}
+
+// halfOpenMaxRequests returns the number of trial requests of a half-open circuit.
+func (b *CircuitBreaker) halfOpenMaxRequests() int {
+	if b.HalfOpenMaxRequests <= 0 {
+		return defaultCircuitHalfOpenMaxRequests
+	}
+	return b.HalfOpenMaxRequests
+}
@@ -0,0 +1,276 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// StatusHTTPClient answers every request with Status and counts the calls.
+type StatusHTTPClient struct {
+	Status atomic.Int32
+	Calls  atomic.Int32
+}
+
+func (c *StatusHTTPClient) Do(_ *http.Request) (*http.Response, error) {
+	c.Calls.Add(1)
+	return newStatusResponse(int(c.Status.Load())), nil
+}
+
+// CloseCountingBody is a request body that counts its calls to Close.
+type CloseCountingBody struct {
+	io.Reader
+	Closes atomic.Int32
+}
+
+func (b *CloseCountingBody) Close() error {
+	b.Closes.Add(1)
+	return nil
+}
+
+type HTTPClientCallCircuitBreakerSuite struct {
+	suite.Suite
+	doer    *StatusHTTPClient
+	breaker *CircuitBreaker
+	changes []string
+	mu      sync.Mutex
+}
+
+func (suite *HTTPClientCallCircuitBreakerSuite) SetupSubTest() {
+	suite.doer = &StatusHTTPClient{}
+	suite.doer.Status.Store(http.StatusInternalServerError)
+	suite.changes = nil
+	suite.breaker = NewCircuitBreaker()
+	suite.breaker.ConsecutiveFailures = 3
+	suite.breaker.OpenDuration = 50 * time.Millisecond
+	suite.breaker.OnStateChange = func(key string, from, to CircuitState) {
+		suite.mu.Lock()
+		defer suite.mu.Unlock()
+		suite.changes = append(suite.changes, key+": "+from.String()+" -> "+to.String())
+	}
+}
+
+func (suite *HTTPClientCallCircuitBreakerSuite) call() error {
+	_, err := NewHTTPClientCall("http://example.com", suite.doer).
+		Method(http.MethodGet).
+		CircuitBreaker(suite.breaker).
+		Do(context.Background())
+	return err
+}
+
+func (suite *HTTPClientCallCircuitBreakerSuite) TestCircuitBreaker() {
+	suite.Run("opens after consecutive failures and rejects requests without calling the doer", func() {
+		for range 3 {
+			suite.NoError(suite.call())
+		}
+		err := suite.call()
+		suite.ErrorIs(err, ErrCircuitOpen)
+		var openErr *CircuitOpenError
+		require.ErrorAs(suite.T(), err, &openErr)
+		suite.Equal("example.com", openErr.Key)
+		suite.Equal(CircuitOpen, openErr.State)
+		suite.Positive(openErr.RetryAfter)
+		suite.EqualError(err, "circuit breaker is open: example.com (open)")
+		suite.Equal(int32(3), suite.doer.Calls.Load())
+		suite.Equal(CircuitOpen, suite.breaker.State("example.com"))
+		suite.Equal([]string{"example.com: closed -> open"}, suite.changes)
+	})
+
+	suite.Run("closes the body of rejected requests", func() {
+		for range 3 {
+			suite.NoError(suite.call())
+		}
+		body := &CloseCountingBody{Reader: strings.NewReader("payload")}
+		_, err := NewHTTPClientCall("http://example.com", suite.doer).
+			Method(http.MethodPost).
+			Body(body).
+			CircuitBreaker(suite.breaker).
+			Do(context.Background())
+		suite.ErrorIs(err, ErrCircuitOpen)
+		suite.Equal(int32(1), body.Closes.Load())
+	})
+
+	suite.Run("resets consecutive failures after a success", func() {
+		suite.NoError(suite.call())
+		suite.NoError(suite.call())
+		suite.doer.Status.Store(http.StatusOK)
+		suite.NoError(suite.call())
+		suite.doer.Status.Store(http.StatusInternalServerError)
+		suite.NoError(suite.call())
+		suite.NoError(suite.call())
+		suite.Equal(CircuitClosed, suite.breaker.State("example.com"))
+	})
+
+	suite.Run("opens when the failure ratio is reached after the minimum requests", func() {
+		suite.breaker.ConsecutiveFailures = 0
+		suite.breaker.MinRequests = 4
+		for i := range 4 {
+			suite.Equal(CircuitClosed, suite.breaker.State("example.com"))
+			if i%2 == 0 {
+				suite.doer.Status.Store(http.StatusOK)
+			} else {
+				suite.doer.Status.Store(http.StatusServiceUnavailable)
+			}
+			suite.NoError(suite.call())
+		}
+		suite.Equal(CircuitOpen, suite.breaker.State("example.com"))
+	})
+
+	suite.Run("closes after successful trial requests in the half-open state", func() {
+		for range 3 {
+			suite.NoError(suite.call())
+		}
+		time.Sleep(60 * time.Millisecond)
+		suite.Equal(CircuitHalfOpen, suite.breaker.State("example.com"))
+		suite.doer.Status.Store(http.StatusOK)
+		suite.NoError(suite.call())
+		suite.Equal(CircuitClosed, suite.breaker.State("example.com"))
+		suite.Equal([]string{
+			"example.com: closed -> open",
+			"example.com: open -> half-open",
+			"example.com: half-open -> closed",
+		}, suite.changes)
+	})
+
+	suite.Run("opens again when a trial request fails", func() {
+		for range 3 {
+			suite.NoError(suite.call())
+		}
+		time.Sleep(60 * time.Millisecond)
+		suite.NoError(suite.call())
+		suite.Equal(CircuitOpen, suite.breaker.State("example.com"))
+		suite.ErrorIs(suite.call(), ErrCircuitOpen)
+	})
+
+	suite.Run("gives the trial slot back when a trial request is cancelled", func() {
+		for range 3 {
+			suite.NoError(suite.call())
+		}
+		time.Sleep(60 * time.Millisecond)
+		cancelled := HTTPClientDoerFunc(func(_ *http.Request) (*http.Response, error) {
+			return nil, context.Canceled
+		})
+		_, err := NewHTTPClientCall("http://example.com", cancelled).
+			Method(http.MethodGet).
+			CircuitBreaker(suite.breaker).
+			Do(context.Background())
+		suite.ErrorIs(err, context.Canceled)
+		suite.Equal(CircuitHalfOpen, suite.breaker.State("example.com"))
+
+		suite.doer.Status.Store(http.StatusOK)
+		suite.NoError(suite.call())
+		suite.Equal(CircuitClosed, suite.breaker.State("example.com"))
+	})
+
+	suite.Run("limits the trial requests in flight", func() {
+		for range 3 {
+			suite.NoError(suite.call())
+		}
+		time.Sleep(60 * time.Millisecond)
+		release := make(chan struct{})
+		started := make(chan struct{})
+		blocking := HTTPClientDoerFunc(func(_ *http.Request) (*http.Response, error) {
+			close(started)
+			<-release
+			return newStatusResponse(http.StatusOK), nil
+		})
+		done := make(chan error)
+		go func() {
+			_, err := NewHTTPClientCall("http://example.com", blocking).
+				Method(http.MethodGet).
+				CircuitBreaker(suite.breaker).
+				Do(context.Background())
+			done <- err
+		}()
+		<-started
+		var openErr *CircuitOpenError
+		suite.ErrorAs(suite.call(), &openErr)
+		suite.Equal(CircuitHalfOpen, openErr.State)
+		close(release)
+		suite.NoError(<-done)
+		suite.Equal(CircuitClosed, suite.breaker.State("example.com"))
+	})
+
+	suite.Run("keeps a circuit per route with RouteKey", func() {
+		suite.breaker.Key = RouteKey
+		var routes []string
+		record := func(next HTTPClientDoer) HTTPClientDoer {
+			return HTTPClientDoerFunc(func(req *http.Request) (*http.Response, error) {
+				routes = append(routes, RouteTemplate(req.Context()))
+				return next.Do(req)
+			})
+		}
+		c := NewHTTPClient("http://example.com", suite.doer,
+			WithCircuitBreaker(suite.breaker),
+			WithMiddlewares(record),
+		)
+		for range 3 {
+			_, err := c.NewCall().Method(http.MethodGet).Path("/users/1").Route("/users/{id}").Do(context.Background())
+			suite.NoError(err)
+		}
+		_, err := c.NewCall().Method(http.MethodGet).Path("/users/2").Route("/users/{id}").Do(context.Background())
+		suite.ErrorIs(err, ErrCircuitOpen)
+		_, err = c.NewCall().Method(http.MethodGet).Path("/orders/1").Route("/orders/{id}").Do(context.Background())
+		suite.NoError(err)
+		suite.Equal(CircuitOpen, suite.breaker.State("example.com/users/{id}"))
+		suite.Equal(CircuitClosed, suite.breaker.State("example.com/orders/{id}"))
+		suite.Equal([]string{"/users/{id}", "/users/{id}", "/users/{id}", "/orders/{id}"}, routes)
+	})
+
+	suite.Run("is not retried by the default retry policy", func() {
+		policy := NewRetryPolicy()
+		policy.InitialBackoff = time.Millisecond
+		suite.doer.Status.Store(http.StatusServiceUnavailable)
+		resp, err := NewHTTPClientCall("http://example.com", suite.doer).
+			Method(http.MethodGet).
+			CircuitBreaker(suite.breaker).
+			Retry(policy).
+			DoWithUnmarshal(context.Background(), &map[string]string{})
+		var httpError *HTTPError
+		suite.ErrorAs(err, &httpError)
+		suite.Equal(3, resp.Attempts)
+
+		_, attempts, err := NewHTTPClientCall("http://example.com", suite.doer).
+			Method(http.MethodGet).
+			CircuitBreaker(suite.breaker).
+			Retry(policy).
+			do(context.Background())
+		suite.ErrorIs(err, ErrCircuitOpen)
+		suite.Equal(1, attempts)
+		suite.Equal(int32(3), suite.doer.Calls.Load())
+	})
+
+	suite.Run("does not count cancelled requests as failures", func() {
+		failing := HTTPClientDoerFunc(func(_ *http.Request) (*http.Response, error) {
+			return nil, context.Canceled
+		})
+		for range 5 {
+			_, err := NewHTTPClientCall("http://example.com", failing).
+				Method(http.MethodGet).
+				CircuitBreaker(suite.breaker).
+				Do(context.Background())
+			suite.True(errors.Is(err, context.Canceled))
+		}
+		suite.Equal(CircuitClosed, suite.breaker.State("example.com"))
+	})
+}
+
+func (suite *HTTPClientCallCircuitBreakerSuite) TestCircuitState_String() {
+	suite.Equal("closed", CircuitClosed.String())
+	suite.Equal("open", CircuitOpen.String())
+	suite.Equal("half-open", CircuitHalfOpen.String())
+	suite.Equal("CircuitState(7)", CircuitState(7).String())
+}
+
+func TestHTTPClientCallCircuitBreakerSuite(t *testing.T) {
+	suite.Run(t, new(HTTPClientCallCircuitBreakerSuite))
+}
@@ -0,0 +1,277 @@
+package client
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// defaultMaxDecompressedSize is the default limit of a decompressed response body.
+const defaultMaxDecompressedSize = 64 << 20
+
+// DefaultCompressionLevel asks the compressor to use its default compression level.
+const DefaultCompressionLevel = -1
+
+// Content codings supported out of the box.
+const (
+	EncodingGzip    = "gzip"
+	EncodingDeflate = "deflate"
+)
+
+// ErrDecompressedSizeExceeded is returned while reading a decompressed response body larger than the allowed size.
+var ErrDecompressedSizeExceeded = errors.New("decompressed response body exceeds the maximum size")
+
+// Decompressor decodes response bodies compressed with a content coding. Implement it to plug in codecs such as
+// zstd or brotli.
+type Decompressor interface {
+	// Encoding returns the content coding name, as used in the Accept-Encoding and Content-Encoding headers.
+	Encoding() string
+	// NewReader returns a reader decompressing r.
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+// Compressor compresses request bodies with a content coding. Implement it to plug in codecs such as zstd or brotli.
+type Compressor interface {
+	// Encoding returns the content coding name, as used in the Content-Encoding header.
+	Encoding() string
+	// NewWriter returns a writer compressing into w with the given level, or the codec default level for
+	// DefaultCompressionLevel.
+	NewWriter(w io.Writer, level int) (io.WriteCloser, error)
+}
+
+// RequestCompression configures the compression of request bodies.
+type RequestCompression struct {
+	// Compressor is the codec used to compress the body.
+	Compressor Compressor
+	// Level is the compression level passed to the compressor.
+	Level int
+	// MinSize is the size in bytes below which encoded bodies are sent uncompressed.
+	MinSize int
+}
+
+// NewRequestCompression creates a RequestCompression with the default compression level and no minimum size.
+func NewRequestCompression(compressor Compressor) *RequestCompression {
+	return &RequestCompression{
+		Compressor: compressor,
+		Level:      DefaultCompressionLevel,
+		MinSize:    0,
+	}
+}
+
+// GzipCodec handles the gzip content coding.
+type GzipCodec struct{}
+
+// Encoding returns gzip.
+func (c *GzipCodec) Encoding() string {
+	return EncodingGzip
+}
+
+// NewReader returns a reader decompressing gzip data.
+func (c *GzipCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// NewWriter returns a writer compressing gzip data with the given level.
+func (c *GzipCodec) NewWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	return gzip.NewWriterLevel(w, level)
+}
+
+// DeflateCodec handles the deflate content coding. It reads both zlib wrapped and raw deflate data.
+type DeflateCodec struct{}
+
+// Encoding returns deflate.
+func (c *DeflateCodec) Encoding() string {
+	return EncodingDeflate
+}
+
+// NewReader returns a reader decompressing deflate data.
+func (c *DeflateCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	reader := bufio.NewReader(r)
+	header, err := reader.Peek(2)
+	if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(reader)
+	}
+	return flate.NewReader(reader), nil
+}
+
+// NewWriter returns a writer compressing zlib wrapped deflate data with the given level.
+func (c *DeflateCodec) NewWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	return zlib.NewWriterLevel(w, level)
+}
+
+// Compression sets the compression of the request body. It takes precedence over UseGzipCompress.
+func (r *HTTPClientCall) Compression(compression *RequestCompression) *HTTPClientCall {
+	r.compression = compression
+	return r
+}
+
+// WithRequestCompression sets the compression of the request body for every request.
+func WithRequestCompression(compression *RequestCompression) ClientOption {
+	return func(c *HTTPClient) {
+		c.compression = compression
+	}
+}
+
+// requestCompression returns the compression applied to the request body, or nil when it is not compressed.
+func (r *HTTPClientCall) requestCompression() *RequestCompression {
+	if r.compression != nil && r.compression.Compressor != nil {
+		return r.compression
+	}
+	if r.gzipCompress {
+		return NewRequestCompression(&GzipCodec{})
+	}
+	return nil
+}
+
+// DecompressResponse enables the negotiation and decoding of compressed responses. The Accept-Encoding header is set
+// from the decompressors, gzip and deflate by default, and the response body is decoded before being returned.
+// DoWithUnmarshal and DoStream decode gzip and deflate responses even without negotiation.
+func (r *HTTPClientCall) DecompressResponse(decompressors ...Decompressor) *HTTPClientCall {
+	r.decompressors = defaultDecompressors(decompressors)
+	return r
+}
+
+// MaxDecompressedSize sets the maximum size of a decompressed response body, to protect against decompression bombs.
+func (r *HTTPClientCall) MaxDecompressedSize(maxDecompressedSize int64) *HTTPClientCall {
+	r.maxDecompressedSize = maxDecompressedSize
+	return r
+}
+
+// WithResponseDecompression enables the negotiation and decoding of compressed responses for every request.
+func WithResponseDecompression(decompressors ...Decompressor) ClientOption {
+	return func(c *HTTPClient) {
+		c.decompressors = defaultDecompressors(decompressors)
+	}
+}
+
+// WithMaxDecompressedSize sets the maximum size of a decompressed response body for every request.
+func WithMaxDecompressedSize(maxDecompressedSize int64) ClientOption {
+	return func(c *HTTPClient) {
+		c.maxDecompressedSize = maxDecompressedSize
+	}
+}
+
+// defaultDecompressors returns the given decompressors, or gzip and deflate when none is given.
+func defaultDecompressors(decompressors []Decompressor) []Decompressor {
+	if len(decompressors) == 0 {
+		return []Decompressor{&GzipCodec{}, &DeflateCodec{}}
+	}
+	return decompressors
+}
+
+// setAcceptEncoding advertises the supported content codings when the request does not set Accept-Encoding.
+func (r *HTTPClientCall) setAcceptEncoding(req *http.Request) {
+	if len(r.decompressors) == 0 || req.Header.Get(HeaderAcceptEncoding) != "" {
+		return
+	}
+	encodings := make([]string, 0, len(r.decompressors))
+	for _, decompressor := range r.decompressors {
+		encodings = append(encodings, decompressor.Encoding())
+	}
+	req.Header.Set(HeaderAcceptEncoding, strings.Join(encodings, ", "))
+}
+
+// decodeResponseContent decodes a response read by a decoder with the negotiated decompressors, or with gzip and
+// deflate without negotiation, since the transport does not decode the responses of requests that set
+// Accept-Encoding themselves.
+func (r *HTTPClientCall) decodeResponseContent(resp *http.Response) error {
+	return r.decompressResponse(resp, defaultDecompressors(r.decompressors))
+}
+
+// decompressResponse replaces the response body with its decoded content when every content coding is supported by
+// the decompressors.
+func (r *HTTPClientCall) decompressResponse(resp *http.Response, supported []Decompressor) error {
+	contentEncoding := resp.Header.Get(HeaderContentEncoding)
+	if len(supported) == 0 || contentEncoding == "" || !hasResponseBody(r.method, resp) {
+		return nil
+	}
+
+	codings := strings.Split(contentEncoding, ",")
+	decompressors := make([]Decompressor, 0, len(codings))
+	for i := len(codings) - 1; i >= 0; i-- {
+		coding := strings.ToLower(strings.TrimSpace(codings[i]))
+		if coding == "identity" {
+			continue
+		}
+		decompressor := findDecompressor(supported, coding)
+		if decompressor == nil {
+			return nil
+		}
+		decompressors = append(decompressors, decompressor)
+	}
+
+	var body io.Reader = resp.Body
+	closers := []io.Closer{resp.Body}
+	for _, decompressor := range decompressors {
+		reader, err := decompressor.NewReader(body)
+		if err != nil {
+			return err
+		}
+		body = reader
+		closers = append(closers, reader)
+	}
+
+	maxSize := r.maxDecompressedSize
+	if maxSize <= 0 {
+		maxSize = defaultMaxDecompressedSize
+	}
+	resp.Body = &decompressedBody{reader: body, closers: closers, remaining: maxSize}
+	resp.Header.Del(HeaderContentEncoding)
+	resp.Header.Del(HeaderContentLength)
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+// hasResponseBody reports whether the response can carry a body.
+func hasResponseBody(method string, resp *http.Response) bool {
+	return resp.Body != nil && resp.Body != http.NoBody && method != http.MethodHead &&
+		resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotModified
+}
+
+// findDecompressor returns the decompressor of the given content coding, or nil if it is not supported.
+func findDecompressor(decompressors []Decompressor, coding string) Decompressor {
+	for _, decompressor := range decompressors {
+		if strings.EqualFold(decompressor.Encoding(), coding) {
+			return decompressor
+		}
+	}
+	return nil
+}
+
+// decompressedBody reads a decompressed response body up to a maximum size and closes every reader of the chain.
+type decompressedBody struct {
+	reader    io.Reader
+	closers   []io.Closer
+	remaining int64
+}
+
+// Read reads decompressed data, failing with ErrDecompressedSizeExceeded once the maximum size is exceeded.
+func (d *decompressedBody) Read(p []byte) (int, error) {
+	if d.remaining < 0 {
+		return 0, ErrDecompressedSizeExceeded
+	}
+	if int64(len(p)) > d.remaining+1 {
+		p = p[:d.remaining+1]
+	}
+	n, err := d.reader.Read(p)
+	d.remaining -= int64(n)
+	if d.remaining < 0 {
+		return n + int(d.remaining), ErrDecompressedSizeExceeded
+	}
+	return n, err
+}
+
+// Close closes the decompressors and the original body.
+func (d *decompressedBody) Close() error {
+	var errs []error
+	for i := len(d.closers) - 1; i >= 0; i-- {
+		errs = append(errs, d.closers[i].Close())
+	}
+	return errors.Join(errs...)
+}
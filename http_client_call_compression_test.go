@@ -0,0 +1,341 @@
+package client
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type reverseCodec struct{}
+
+func (c *reverseCodec) Encoding() string { return "reverse" }
+
+func (c *reverseCodec) NewWriter(w io.Writer, _ int) (io.WriteCloser, error) {
+	return &reverseWriter{w: w}, nil
+}
+
+type reverseWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+func (r *reverseWriter) Write(p []byte) (int, error) {
+	r.buf = append(r.buf, p...)
+	return len(p), nil
+}
+
+func (r *reverseWriter) Close() error {
+	for i, j := 0, len(r.buf)-1; i < j; i, j = i+1, j-1 {
+		r.buf[i], r.buf[j] = r.buf[j], r.buf[i]
+	}
+	_, err := r.w.Write(r.buf)
+	return err
+}
+
+func (c *reverseCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(data)-1; i < j; i, j = i+1, j-1 {
+		data[i], data[j] = data[j], data[i]
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+type HTTPClientCallCompressionSuite struct {
+	suite.Suite
+}
+
+func gzipData(data string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, _ = gz.Write([]byte(data))
+	_ = gz.Close()
+	return buf.Bytes()
+}
+
+func compressedResponse(encoding string, body []byte) *http.Response {
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		ContentLength: int64(len(body)),
+		Header: http.Header{
+			HeaderContentType:     []string{MIMEApplicationJSON},
+			HeaderContentEncoding: []string{encoding},
+			HeaderContentLength:   []string{"1"},
+		},
+		Body: io.NopCloser(bytes.NewReader(body)),
+	}
+}
+
+func (suite *HTTPClientCallCompressionSuite) TestDeflateCodec() {
+	for name, compress := range map[string]func(io.Writer) io.WriteCloser{
+		"zlib": func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+		"raw": func(w io.Writer) io.WriteCloser {
+			fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+			return fw
+		},
+	} {
+		suite.Run("reads "+name+" data", func() {
+			var buf bytes.Buffer
+			w := compress(&buf)
+			_, _ = w.Write([]byte("payload"))
+			_ = w.Close()
+
+			reader, err := (&DeflateCodec{}).NewReader(&buf)
+			require.NoError(suite.T(), err)
+			data, err := io.ReadAll(reader)
+			require.NoError(suite.T(), err)
+			suite.Equal("payload", string(data))
+		})
+	}
+}
+
+func (suite *HTTPClientCallCompressionSuite) TestDecompressResponse() {
+	suite.Run("negotiates Accept-Encoding and decodes gzip responses", func() {
+		doer := &SequenceHTTPClient{Responses: []*http.Response{compressedResponse("gzip", gzipData(`{"key":"value"}`))}}
+		var requests []*http.Request
+		capture := func(next HTTPClientDoer) HTTPClientDoer {
+			return HTTPClientDoerFunc(func(req *http.Request) (*http.Response, error) {
+				requests = append(requests, req)
+				return next.Do(req)
+			})
+		}
+
+		var body map[string]string
+		resp, err := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodGet).
+			Use(capture).
+			DecompressResponse().
+			DoWithUnmarshal(context.Background(), &body)
+		require.NoError(suite.T(), err)
+		suite.Equal("value", body["key"])
+		suite.Equal("gzip, deflate", requests[0].Header.Get(HeaderAcceptEncoding))
+		suite.Empty(resp.Header.Get(HeaderContentEncoding))
+		suite.Empty(resp.Header.Get(HeaderContentLength))
+	})
+
+	suite.Run("keeps an Accept-Encoding header set on the request", func() {
+		doer := &RecordingHTTPClient{}
+		_, err := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodGet).
+			Headers(http.Header{HeaderAcceptEncoding: []string{"gzip"}}).
+			DecompressResponse().
+			Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Equal([]string{"gzip"}, doer.Requests[0].Header.Values(HeaderAcceptEncoding))
+	})
+
+	suite.Run("decodes stacked codings with pluggable codecs", func() {
+		reversed := []byte(`{"key":"value"}`)
+		for i, j := 0, len(reversed)-1; i < j; i, j = i+1, j-1 {
+			reversed[i], reversed[j] = reversed[j], reversed[i]
+		}
+		doer := &MockHTTPClient{Response: compressedResponse("reverse, gzip", gzipData(string(reversed)))}
+
+		var body map[string]string
+		_, err := NewHTTPClient("http://example.com", doer, WithResponseDecompression(&GzipCodec{}, &reverseCodec{})).
+			NewCall().
+			Method(http.MethodGet).
+			DoWithUnmarshal(context.Background(), &body)
+		require.NoError(suite.T(), err)
+		suite.Equal("value", body["key"])
+	})
+
+	suite.Run("leaves unsupported codings untouched", func() {
+		doer := &MockHTTPClient{Response: compressedResponse("br", []byte("brotli"))}
+		resp, err := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodGet).
+			DecompressResponse().
+			Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Equal("br", resp.Header.Get(HeaderContentEncoding))
+		data, _ := io.ReadAll(resp.Body)
+		suite.Equal("brotli", string(data))
+	})
+
+	suite.Run("decodes gzip and deflate responses without negotiation", func() {
+		doer := &SequenceHTTPClient{Responses: []*http.Response{compressedResponse("gzip", gzipData(`{"key":"value"}`))}}
+		var requests []*http.Request
+		capture := func(next HTTPClientDoer) HTTPClientDoer {
+			return HTTPClientDoerFunc(func(req *http.Request) (*http.Response, error) {
+				requests = append(requests, req)
+				return next.Do(req)
+			})
+		}
+
+		var body map[string]string
+		_, err := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodGet).
+			Headers(http.Header{HeaderAcceptEncoding: []string{"gzip"}}).
+			Use(capture).
+			DoWithUnmarshal(context.Background(), &body)
+		require.NoError(suite.T(), err)
+		suite.Equal("value", body["key"])
+		suite.Equal("gzip", requests[0].Header.Get(HeaderAcceptEncoding))
+	})
+
+	suite.Run("does not decompress Do responses when disabled", func() {
+		compressed := gzipData("payload")
+		doer := &MockHTTPClient{Response: compressedResponse("gzip", compressed)}
+		resp, err := NewHTTPClientCall("http://example.com", doer).Method(http.MethodGet).Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Equal("gzip", resp.Header.Get(HeaderContentEncoding))
+		data, _ := io.ReadAll(resp.Body)
+		suite.Equal(compressed, data)
+	})
+
+	suite.Run("does not negotiate Accept-Encoding when disabled", func() {
+		doer := &RecordingHTTPClient{}
+		_, err := NewHTTPClientCall("http://example.com", doer).Method(http.MethodGet).Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Empty(doer.Requests[0].Header.Get(HeaderAcceptEncoding))
+	})
+
+	suite.Run("leaves pluggable codings untouched when disabled", func() {
+		doer := &MockHTTPClient{Response: compressedResponse("reverse", []byte("daolyap"))}
+		resp, err := NewHTTPClientCall("http://example.com", doer).Method(http.MethodGet).Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Equal("reverse", resp.Header.Get(HeaderContentEncoding))
+		data, _ := io.ReadAll(resp.Body)
+		suite.Equal("daolyap", string(data))
+	})
+
+	suite.Run("ignores HEAD responses", func() {
+		doer := &MockHTTPClient{Response: compressedResponse("gzip", nil)}
+		_, err := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodHead).
+			DecompressResponse().
+			Do(context.Background())
+		suite.NoError(err)
+	})
+
+	suite.Run("returns an error for invalid compressed data", func() {
+		doer := &MockHTTPClient{Response: compressedResponse("gzip", []byte("not gzip"))}
+		_, err := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodGet).
+			DecompressResponse().
+			Do(context.Background())
+		suite.Error(err)
+	})
+}
+
+func (suite *HTTPClientCallCompressionSuite) TestMaxDecompressedSize() {
+	suite.Run("fails when the decompressed body exceeds the maximum size", func() {
+		doer := &MockHTTPClient{Response: compressedResponse("gzip", gzipData(strings.Repeat("a", 1024)))}
+		resp, err := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodGet).
+			DecompressResponse().
+			MaxDecompressedSize(100).
+			Do(context.Background())
+		require.NoError(suite.T(), err)
+		data, err := io.ReadAll(resp.Body)
+		suite.ErrorIs(err, ErrDecompressedSizeExceeded)
+		suite.Len(data, 100)
+		suite.NoError(resp.Body.Close())
+	})
+
+	suite.Run("reads bodies of exactly the maximum size", func() {
+		doer := &MockHTTPClient{Response: compressedResponse("gzip", gzipData(strings.Repeat("a", 100)))}
+		resp, err := NewHTTPClient("http://example.com", doer, WithResponseDecompression(), WithMaxDecompressedSize(100)).
+			NewCall().
+			Method(http.MethodGet).
+			Do(context.Background())
+		require.NoError(suite.T(), err)
+		data, err := io.ReadAll(resp.Body)
+		suite.NoError(err)
+		suite.Len(data, 100)
+	})
+}
+
+func (suite *HTTPClientCallCompressionSuite) TestRequestCompression() {
+	body := map[string]string{"key": strings.Repeat("value", 20)}
+
+	suite.Run("compresses the body with the codec and level", func() {
+		for _, compressor := range []interface {
+			Compressor
+			Decompressor
+		}{&GzipCodec{}, &DeflateCodec{}} {
+			doer := &RecordingHTTPClient{}
+			compression := NewRequestCompression(compressor)
+			compression.Level = 9
+			_, err := NewHTTPClientCall("http://example.com", doer).
+				Method(http.MethodPost).
+				Body(body).
+				Compression(compression).
+				Do(context.Background())
+			require.NoError(suite.T(), err)
+
+			req := doer.Requests[0]
+			suite.Equal(compressor.Encoding(), req.Header.Get(HeaderContentEncoding))
+			reader, err := compressor.NewReader(strings.NewReader(doer.Bodies[0]))
+			require.NoError(suite.T(), err)
+			data, err := io.ReadAll(reader)
+			require.NoError(suite.T(), err)
+			suite.JSONEq(`{"key":"`+strings.Repeat("value", 20)+`"}`, string(data))
+			suite.Equal(int64(len(doer.Bodies[0])), req.ContentLength)
+		}
+	})
+
+	suite.Run("skips compression below the minimum size", func() {
+		doer := &RecordingHTTPClient{}
+		compression := NewRequestCompression(&GzipCodec{})
+		compression.MinSize = 1024
+		_, err := NewHTTPClient("http://example.com", doer, WithRequestCompression(compression)).
+			NewCall().
+			Method(http.MethodPost).
+			Body(body).
+			Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Empty(doer.Requests[0].Header.Get(HeaderContentEncoding))
+		suite.JSONEq(`{"key":"`+strings.Repeat("value", 20)+`"}`, doer.Bodies[0])
+	})
+
+	suite.Run("uses pluggable codecs for streamed bodies", func() {
+		doer := &RecordingHTTPClient{}
+		_, err := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodPut).
+			Body(strings.NewReader("payload")).
+			Compression(NewRequestCompression(&reverseCodec{})).
+			Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Equal("reverse", doer.Requests[0].Header.Get(HeaderContentEncoding))
+		suite.Equal("daolyap", doer.Bodies[0])
+	})
+
+	suite.Run("takes precedence over UseGzipCompress", func() {
+		doer := &RecordingHTTPClient{}
+		_, err := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodPost).
+			Body(body).
+			UseGzipCompress(true).
+			Compression(NewRequestCompression(&DeflateCodec{})).
+			Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Equal(EncodingDeflate, doer.Requests[0].Header.Get(HeaderContentEncoding))
+	})
+
+	suite.Run("returns compressor errors", func() {
+		compression := NewRequestCompression(&GzipCodec{})
+		compression.Level = 42
+		_, err := NewHTTPClientCall("http://example.com", &RecordingHTTPClient{}).
+			Method(http.MethodPost).
+			Body(body).
+			Compression(compression).
+			Do(context.Background())
+		suite.Error(err)
+	})
+}
+
+func TestHTTPClientCallCompressionSuite(t *testing.T) {
+	suite.Run(t, new(HTTPClientCallCompressionSuite))
+}
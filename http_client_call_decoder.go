@@ -2,9 +2,15 @@ package client
 
 import (
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"io"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
 	"strings"
+	"sync"
 )
 
 // ResponseDecoder is an interface for decoding an HTTP response body.
@@ -20,31 +26,323 @@ func (d *JSONResponseDecoder) Decode(r io.Reader, v any) error {
 	return json.NewDecoder(r).Decode(v)
 }
 
+// XMLResponseDecoder decodes XML-encoded HTTP response bodies.
+type XMLResponseDecoder struct{}
+
+// Decode decodes an XML-encoded response body into the provided interface.
+func (d *XMLResponseDecoder) Decode(r io.Reader, v any) error {
+	return xml.NewDecoder(r).Decode(v)
+}
+
+// FormResponseDecoder decodes URL-encoded form HTTP response bodies into *url.Values, *map[string]string or a
+// pointer to a struct whose fields are tagged with `form:"name"`.
+type FormResponseDecoder struct{}
+
+// Decode decodes a URL-encoded form response body into the provided interface.
+func (d *FormResponseDecoder) Decode(r io.Reader, v any) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return err
+	}
+
+	switch target := v.(type) {
+	case *url.Values:
+		*target = values
+		return nil
+	case *map[string]string:
+		*target = make(map[string]string, len(values))
+		for key := range values {
+			(*target)[key] = values.Get(key)
+		}
+		return nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("FormResponseDecoder: unsupported type %T", v)
+	}
+	return decodeFormStruct(values, rv.Elem())
+}
+
+// decodeFormStruct sets the fields of a struct tagged with `form:"name"` from the form values.
+func decodeFormStruct(values url.Values, rv reflect.Value) error {
+	rt := rv.Type()
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("form"), ",")
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+		fieldValues, ok := values[name]
+		if !ok || len(fieldValues) == 0 {
+			continue
+		}
+		if err := setFormField(rv.Field(i), fieldValues); err != nil {
+			return fmt.Errorf("FormResponseDecoder: field %s: %w", field.Name, err)
+		}
+	}
+	return nil
+}
+
+// setFormField sets a struct field from its form values. Slices receive every value, other kinds the first one.
+func setFormField(field reflect.Value, values []string) error {
+	if field.Kind() != reflect.Slice {
+		return setFormValue(field, values[0])
+	}
+	slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+	for i, value := range values {
+		if err := setFormValue(slice.Index(i), value); err != nil {
+			return err
+		}
+	}
+	field.Set(slice)
+	return nil
+}
+
+// setFormValue parses a single form value into a string, boolean or numeric value.
+func setFormValue(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported kind %s", field.Kind())
+	}
+	return nil
+}
+
 // StringResponseDecoder decodes plain text or HTML-encoded HTTP response bodies.
 type StringResponseDecoder struct{}
 
-// Decode decodes a plain text or HTML-encoded response body into the provided string.
+// Decode decodes a plain text or HTML-encoded response body into the provided *string, *[]byte or io.Writer.
 func (d *StringResponseDecoder) Decode(r io.Reader, v any) error {
-	if str, ok := v.(*string); ok {
+	switch target := v.(type) {
+	case *string:
 		data, err := io.ReadAll(r)
 		if err != nil {
 			return err
 		}
-		*str = string(data)
+		*target = string(data)
 		return nil
+	case *[]byte, io.Writer:
+		return (&BinaryResponseDecoder{}).Decode(r, target)
+	default:
+		return fmt.Errorf("StringResponseDecoder: unsupported type %T", v)
 	}
-	return fmt.Errorf("StringResponseDecoder: unsupported type %T", v)
 }
 
-// selectDecoder selects the appropriate ResponseDecoder based on the Content-Type of the response.
-func selectDecoder(contentType string) ResponseDecoder {
-	switch {
-	case strings.Contains(contentType, MIMEApplicationJSON):
-		return &JSONResponseDecoder{}
-	case strings.Contains(contentType, MIMETextPlain), strings.Contains(contentType, MIMETextHTML):
-		return &StringResponseDecoder{}
-	// Add more cases as needed
-	default:
+// FilePath is a decoding destination that streams the response body into the file at the given path.
+type FilePath string
+
+// BinaryResponseDecoder decodes binary HTTP response bodies into a *[]byte, an io.Writer or a FilePath.
+// Writers and files receive the body as it is read, without buffering it in memory.
+type BinaryResponseDecoder struct{}
+
+// Decode copies the response body into the provided destination.
+func (d *BinaryResponseDecoder) Decode(r io.Reader, v any) error {
+	switch target := v.(type) {
+	case *[]byte:
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		*target = data
 		return nil
+	case io.Writer:
+		_, err := io.Copy(target, r)
+		return err
+	case FilePath:
+		return writeFile(string(target), r)
+	case *FilePath:
+		return writeFile(string(*target), r)
+	default:
+		return fmt.Errorf("BinaryResponseDecoder: unsupported type %T", v)
+	}
+}
+
+// isBinaryDestination reports whether v is a destination of BinaryResponseDecoder, used for unknown content types.
+func isBinaryDestination(v any) bool {
+	switch v.(type) {
+	case *[]byte, io.Writer, FilePath, *FilePath:
+		return true
+	default:
+		return false
+	}
+}
+
+// writeFile streams r into the file at path, removing the file if the copy fails.
+func writeFile(path string, r io.Reader) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, r)
+	if errClose := file.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		_ = os.Remove(path)
+	}
+	return err
+}
+
+// DecoderRegistry maps media type patterns to response decoders. It is safe for concurrent use.
+// A pattern is an exact media type such as application/json, a structured syntax suffix such as +json or a
+// wildcard such as text/* or */*.
+type DecoderRegistry struct {
+	decoders map[string]ResponseDecoder
+	fallback ResponseDecoder
+	mu       sync.RWMutex
+}
+
+// NewDecoderRegistry creates a DecoderRegistry with the built-in decoders for JSON, XML, forms, text and binary
+// content such as octet streams, PDF, ZIP, images, audio, video and fonts.
+func NewDecoderRegistry() *DecoderRegistry {
+	registry := &DecoderRegistry{
+		decoders: make(map[string]ResponseDecoder),
+		fallback: nil,
+		mu:       sync.RWMutex{},
+	}
+	registry.Register(MIMEApplicationJSON, &JSONResponseDecoder{})
+	registry.Register("+json", &JSONResponseDecoder{})
+	registry.Register(MIMEApplicationXML, &XMLResponseDecoder{})
+	registry.Register(MIMETextXML, &XMLResponseDecoder{})
+	registry.Register("+xml", &XMLResponseDecoder{})
+	registry.Register(MIMEApplicationForm, &FormResponseDecoder{})
+	registry.Register(MIMETextPlain, &StringResponseDecoder{})
+	registry.Register(MIMETextHTML, &StringResponseDecoder{})
+	registry.Register(MIMEOctetStream, &BinaryResponseDecoder{})
+	registry.Register(MIMEApplicationPDF, &BinaryResponseDecoder{})
+	registry.Register(MIMEApplicationZip, &BinaryResponseDecoder{})
+	registry.Register("image/*", &BinaryResponseDecoder{})
+	registry.Register("audio/*", &BinaryResponseDecoder{})
+	registry.Register("video/*", &BinaryResponseDecoder{})
+	registry.Register("font/*", &BinaryResponseDecoder{})
+	return registry
+}
+
+// Register sets the decoder used for the given media type pattern.
+func (d *DecoderRegistry) Register(pattern string, decoder ResponseDecoder) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.decoders[strings.ToLower(pattern)] = decoder
+}
+
+// SetFallback sets the decoder used when no pattern matches the Content-Type.
+func (d *DecoderRegistry) SetFallback(decoder ResponseDecoder) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.fallback = decoder
+}
+
+// Lookup returns the decoder for the media type of the given Content-Type, trying the exact media type, its
+// structured syntax suffix, the type/* and */* wildcards and finally the fallback decoder, which may be nil.
+func (d *DecoderRegistry) Lookup(contentType string) ResponseDecoder {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	mediaType, ok := parseMediaType(contentType)
+	if !ok {
+		return d.fallback
+	}
+	if decoder, ok := d.decoders[mediaType]; ok {
+		return decoder
 	}
+	mainType, subType, _ := strings.Cut(mediaType, "/")
+	if i := strings.LastIndexByte(subType, '+'); i >= 0 {
+		if decoder, ok := d.decoders[subType[i:]]; ok {
+			return decoder
+		}
+	}
+	if decoder, ok := d.decoders[mainType+"/*"]; ok {
+		return decoder
+	}
+	if decoder, ok := d.decoders["*/*"]; ok {
+		return decoder
+	}
+	return d.fallback
+}
+
+// Clone returns a copy of the registry.
+func (d *DecoderRegistry) Clone() *DecoderRegistry {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	decoders := make(map[string]ResponseDecoder, len(d.decoders))
+	for pattern, decoder := range d.decoders {
+		decoders[pattern] = decoder
+	}
+	return &DecoderRegistry{
+		decoders: decoders,
+		fallback: d.fallback,
+		mu:       sync.RWMutex{},
+	}
+}
+
+// defaultDecoders is the registry used by requests that do not set their own.
+var defaultDecoders = NewDecoderRegistry()
+
+// Decoder sets the decoder for the HTTP response body, regardless of its Content-Type.
+func (r *HTTPClientCall) Decoder(decoder ResponseDecoder) *HTTPClientCall {
+	r.decoder = decoder
+	return r
+}
+
+// WithResponseDecoder registers a decoder for a media type pattern on the client, on top of the built-in decoders.
+func WithResponseDecoder(pattern string, decoder ResponseDecoder) ClientOption {
+	return func(c *HTTPClient) {
+		if c.decoders == nil {
+			c.decoders = defaultDecoders.Clone()
+		}
+		c.decoders.Register(pattern, decoder)
+	}
+}
+
+// WithFallbackDecoder sets the decoder used by the client when no registered pattern matches the Content-Type.
+func WithFallbackDecoder(decoder ResponseDecoder) ClientOption {
+	return func(c *HTTPClient) {
+		if c.decoders == nil {
+			c.decoders = defaultDecoders.Clone()
+		}
+		c.decoders.SetFallback(decoder)
+	}
+}
+
+// lookupDecoder returns the decoder registered for the Content-Type in the request registry, or in the default one.
+func (r *HTTPClientCall) lookupDecoder(contentType string) ResponseDecoder {
+	if r.decoders == nil {
+		return defaultDecoders.Lookup(contentType)
+	}
+	return r.decoders.Lookup(contentType)
+}
+
+// selectDecoder selects the appropriate ResponseDecoder from the default registry based on the Content-Type
+// of the response.
+func selectDecoder(contentType string) ResponseDecoder {
+	return defaultDecoders.Lookup(contentType)
 }
@@ -2,8 +2,15 @@ package client
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
 	"testing"
+	"testing/iotest"
 
 	"github.com/stretchr/testify/suite"
 )
@@ -35,6 +42,88 @@ func (suite *HTTPClientCallDecoderSuite) TestJSONResponseDecoder_Decode() {
 	})
 }
 
+func (suite *HTTPClientCallDecoderSuite) TestXMLResponseDecoder_Decode() {
+	type item struct {
+		Name string `xml:"name"`
+	}
+
+	suite.Run("decodes XML response successfully", func() {
+		decoder := &XMLResponseDecoder{}
+		r := io.NopCloser(bytes.NewBufferString(`<?xml version="1.0"?><item><name>value</name></item>`))
+		var result item
+
+		err := decoder.Decode(r, &result)
+		suite.NoError(err)
+		suite.Equal("value", result.Name)
+	})
+
+	suite.Run("returns error for invalid XML", func() {
+		decoder := &XMLResponseDecoder{}
+		r := io.NopCloser(bytes.NewBufferString(`<item><name>value</item>`))
+		var result item
+
+		err := decoder.Decode(r, &result)
+		suite.Error(err)
+	})
+}
+
+func (suite *HTTPClientCallDecoderSuite) TestFormResponseDecoder_Decode() {
+	body := "access_token=abc&expires_in=3600&scope=read&scope=write&refresh=true&ratio=0.5"
+
+	suite.Run("decodes into url.Values", func() {
+		var result url.Values
+		err := (&FormResponseDecoder{}).Decode(bytes.NewBufferString(body), &result)
+		suite.NoError(err)
+		suite.Equal("abc", result.Get("access_token"))
+		suite.Equal([]string{"read", "write"}, result["scope"])
+	})
+
+	suite.Run("decodes into map[string]string", func() {
+		var result map[string]string
+		err := (&FormResponseDecoder{}).Decode(bytes.NewBufferString(body), &result)
+		suite.NoError(err)
+		suite.Equal("abc", result["access_token"])
+		suite.Equal("read", result["scope"])
+	})
+
+	suite.Run("decodes into a tagged struct", func() {
+		var result struct {
+			AccessToken string   `form:"access_token"`
+			Scopes      []string `form:"scope"`
+			Ignored     string   `form:"-"`
+			Untagged    string
+			ExpiresIn   int     `form:"expires_in"`
+			Ratio       float64 `form:"ratio"`
+			Missing     uint    `form:"missing"`
+			Refresh     bool    `form:"refresh"`
+		}
+		err := (&FormResponseDecoder{}).Decode(bytes.NewBufferString(body), &result)
+		suite.NoError(err)
+		suite.Equal("abc", result.AccessToken)
+		suite.Equal([]string{"read", "write"}, result.Scopes)
+		suite.Equal(3600, result.ExpiresIn)
+		suite.Equal(0.5, result.Ratio)
+		suite.True(result.Refresh)
+		suite.Empty(result.Ignored)
+		suite.Empty(result.Untagged)
+		suite.Zero(result.Missing)
+	})
+
+	suite.Run("returns error for invalid values", func() {
+		var result struct {
+			ExpiresIn int `form:"expires_in"`
+		}
+		err := (&FormResponseDecoder{}).Decode(bytes.NewBufferString("expires_in=soon"), &result)
+		suite.ErrorContains(err, "FormResponseDecoder: field ExpiresIn")
+	})
+
+	suite.Run("returns error for unsupported type", func() {
+		var result string
+		err := (&FormResponseDecoder{}).Decode(bytes.NewBufferString(body), &result)
+		suite.EqualError(err, "FormResponseDecoder: unsupported type *string")
+	})
+}
+
 func (suite *HTTPClientCallDecoderSuite) TestStringResponseDecoder_Decode() {
 	suite.Run("decodes plain text response successfully", func() {
 		decoder := &StringResponseDecoder{}
@@ -68,6 +157,65 @@ func (suite *HTTPClientCallDecoderSuite) TestStringResponseDecoder_Decode() {
 		suite.Error(err)
 		suite.EqualError(err, "StringResponseDecoder: unsupported type *int")
 	})
+
+	suite.Run("decodes into byte slices and writers", func() {
+		decoder := &StringResponseDecoder{}
+		var data []byte
+		suite.NoError(decoder.Decode(bytes.NewBufferString("Hello"), &data))
+		suite.Equal([]byte("Hello"), data)
+
+		var buf bytes.Buffer
+		suite.NoError(decoder.Decode(bytes.NewBufferString("Hello"), &buf))
+		suite.Equal("Hello", buf.String())
+	})
+}
+
+func (suite *HTTPClientCallDecoderSuite) TestBinaryResponseDecoder_Decode() {
+	payload := []byte{0x89, 'P', 'N', 'G', 0x00, 0xff}
+
+	suite.Run("decodes into a byte slice", func() {
+		var result []byte
+		err := (&BinaryResponseDecoder{}).Decode(bytes.NewReader(payload), &result)
+		suite.NoError(err)
+		suite.Equal(payload, result)
+	})
+
+	suite.Run("streams into a writer", func() {
+		var buf bytes.Buffer
+		err := (&BinaryResponseDecoder{}).Decode(bytes.NewReader(payload), &buf)
+		suite.NoError(err)
+		suite.Equal(payload, buf.Bytes())
+	})
+
+	suite.Run("streams into a file path", func() {
+		path := filepath.Join(suite.T().TempDir(), "image.png")
+		err := (&BinaryResponseDecoder{}).Decode(bytes.NewReader(payload), FilePath(path))
+		suite.NoError(err)
+		data, err := os.ReadFile(path)
+		suite.NoError(err)
+		suite.Equal(payload, data)
+
+		filePath := FilePath(path)
+		err = (&BinaryResponseDecoder{}).Decode(bytes.NewReader([]byte("new")), &filePath)
+		suite.NoError(err)
+		data, err = os.ReadFile(path)
+		suite.NoError(err)
+		suite.Equal("new", string(data))
+	})
+
+	suite.Run("removes the file when the body cannot be read", func() {
+		path := filepath.Join(suite.T().TempDir(), "broken.bin")
+		body := io.MultiReader(bytes.NewReader(payload), iotest.ErrReader(errors.New("connection reset")))
+		err := (&BinaryResponseDecoder{}).Decode(body, FilePath(path))
+		suite.EqualError(err, "connection reset")
+		suite.NoFileExists(path)
+	})
+
+	suite.Run("returns error for unsupported type", func() {
+		var result string
+		err := (&BinaryResponseDecoder{}).Decode(bytes.NewReader(payload), &result)
+		suite.EqualError(err, "BinaryResponseDecoder: unsupported type *string")
+	})
 }
 
 func (suite *HTTPClientCallDecoderSuite) TestSelectDecoder() {
@@ -78,6 +226,13 @@ func (suite *HTTPClientCallDecoderSuite) TestSelectDecoder() {
 		suite.True(ok)
 	})
 
+	suite.Run("selects JSONResponseDecoder for application/problem+json", func() {
+		contentType := "application/problem+json"
+		decoder := selectDecoder(contentType)
+		_, ok := decoder.(*JSONResponseDecoder)
+		suite.True(ok)
+	})
+
 	suite.Run("selects StringResponseDecoder for text/plain", func() {
 		contentType := "text/plain"
 		decoder := selectDecoder(contentType)
@@ -92,13 +247,181 @@ func (suite *HTTPClientCallDecoderSuite) TestSelectDecoder() {
 		suite.True(ok)
 	})
 
+	suite.Run("selects XMLResponseDecoder for XML content types", func() {
+		for _, contentType := range []string{MIMEApplicationXMLCharsetUTF8, MIMETextXML, "application/atom+xml"} {
+			decoder := selectDecoder(contentType)
+			_, ok := decoder.(*XMLResponseDecoder)
+			suite.True(ok, contentType)
+		}
+	})
+
+	suite.Run("selects FormResponseDecoder for application/x-www-form-urlencoded", func() {
+		contentType := "application/x-www-form-urlencoded"
+		decoder := selectDecoder(contentType)
+		_, ok := decoder.(*FormResponseDecoder)
+		suite.True(ok)
+	})
+
+	suite.Run("selects BinaryResponseDecoder for binary content types", func() {
+		for _, contentType := range []string{MIMEOctetStream, MIMEApplicationPDF, MIMEApplicationZip, "image/png", "audio/mpeg", "video/mp4"} {
+			decoder := selectDecoder(contentType)
+			_, ok := decoder.(*BinaryResponseDecoder)
+			suite.True(ok, contentType)
+		}
+	})
+
 	suite.Run("returns nil for unsupported content type", func() {
-		contentType := "application/xml"
+		contentType := "application/vnd.example"
 		decoder := selectDecoder(contentType)
 		suite.Nil(decoder)
 	})
 }
 
+func (suite *HTTPClientCallDecoderSuite) TestDecoderRegistry() {
+	suite.Run("looks up decoders by media type, suffix and wildcard", func() {
+		registry := NewDecoderRegistry()
+		registry.Register("image/*", &StringResponseDecoder{})
+		suite.IsType(&JSONResponseDecoder{}, registry.Lookup(MIMEApplicationJSONCharsetUTF8))
+		suite.IsType(&JSONResponseDecoder{}, registry.Lookup("application/vnd.api+json"))
+		suite.IsType(&XMLResponseDecoder{}, registry.Lookup("Application/Atom+XML"))
+		suite.IsType(&FormResponseDecoder{}, registry.Lookup(MIMEApplicationForm))
+		suite.IsType(&StringResponseDecoder{}, registry.Lookup("text/html; charset=utf-8"))
+		suite.IsType(&StringResponseDecoder{}, registry.Lookup("image/png"))
+		suite.IsType(&JSONResponseDecoder{}, registry.Lookup("application/json; charset"))
+		suite.Nil(registry.Lookup("application/unknown"))
+		suite.Nil(registry.Lookup(""))
+	})
+
+	suite.Run("prefers the exact media type over the suffix", func() {
+		registry := NewDecoderRegistry()
+		registry.Register("application/vnd.custom+json", &StringResponseDecoder{})
+		suite.IsType(&StringResponseDecoder{}, registry.Lookup("application/vnd.custom+json"))
+		suite.IsType(&JSONResponseDecoder{}, registry.Lookup("application/vnd.other+json"))
+	})
+
+	suite.Run("returns the fallback decoder when nothing matches", func() {
+		registry := NewDecoderRegistry()
+		registry.Register("*/*", &StringResponseDecoder{})
+		suite.IsType(&StringResponseDecoder{}, registry.Lookup("application/unknown"))
+
+		registry = NewDecoderRegistry()
+		registry.SetFallback(&JSONResponseDecoder{})
+		suite.IsType(&JSONResponseDecoder{}, registry.Lookup("application/unknown"))
+		suite.IsType(&JSONResponseDecoder{}, registry.Lookup("invalid content type;"))
+	})
+
+	suite.Run("clones without sharing registrations", func() {
+		registry := NewDecoderRegistry()
+		clone := registry.Clone()
+		clone.Register("application/vnd.custom", &StringResponseDecoder{})
+		clone.SetFallback(&StringResponseDecoder{})
+		suite.NotNil(clone.Lookup("application/vnd.custom"))
+		suite.Nil(registry.Lookup("application/vnd.custom"))
+		suite.Nil(registry.Lookup("application/unknown"))
+	})
+}
+
+func (suite *HTTPClientCallDecoderSuite) TestDoWithUnmarshal_Decoders() {
+	newResponse := func(contentType, body string) *http.Response {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{HeaderContentType: []string{contentType}},
+			Body:       io.NopCloser(bytes.NewBufferString(body)),
+		}
+	}
+
+	suite.Run("uses the decoder set on the request", func() {
+		doer := &MockHTTPClient{Response: newResponse("application/vnd.custom", "custom")}
+		var result string
+		_, err := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodGet).
+			Decoder(&StringResponseDecoder{}).
+			DoWithUnmarshal(context.Background(), &result)
+		suite.NoError(err)
+		suite.Equal("custom", result)
+	})
+
+	suite.Run("uses the decoders registered on the client", func() {
+		doer := &MockHTTPClient{Response: newResponse("application/vnd.custom", "custom")}
+		c := NewHTTPClient("http://example.com", doer,
+			WithResponseDecoder("application/vnd.custom", &StringResponseDecoder{}),
+		)
+		var result string
+		_, err := c.NewCall().Method(http.MethodGet).DoWithUnmarshal(context.Background(), &result)
+		suite.NoError(err)
+		suite.Equal("custom", result)
+		suite.Nil(defaultDecoders.Lookup("application/vnd.custom"))
+	})
+
+	suite.Run("uses the fallback decoder registered on the client", func() {
+		doer := &MockHTTPClient{Response: newResponse("application/vnd.unknown", `{"key":"value"}`)}
+		c := NewHTTPClient("http://example.com", doer, WithFallbackDecoder(&JSONResponseDecoder{}))
+		var result map[string]string
+		_, err := c.NewCall().Method(http.MethodGet).DoWithUnmarshal(context.Background(), &result)
+		suite.NoError(err)
+		suite.Equal("value", result["key"])
+	})
+
+	suite.Run("decodes error bodies with the client decoders", func() {
+		doer := &MockHTTPClient{Response: &http.Response{
+			StatusCode: http.StatusBadRequest,
+			Header:     http.Header{HeaderContentType: []string{"application/vnd.error"}},
+			Body:       io.NopCloser(bytes.NewBufferString("bad request")),
+		}}
+		c := NewHTTPClient("http://example.com", doer,
+			WithResponseDecoder("application/vnd.error", &StringResponseDecoder{}),
+		)
+		var errorBody string
+		_, err := c.NewCall().Method(http.MethodGet).ErrorBody(&errorBody).
+			DoWithUnmarshal(context.Background(), &map[string]string{})
+		var httpError *HTTPError
+		suite.ErrorAs(err, &httpError)
+		suite.Equal("bad request", errorBody)
+	})
+
+	suite.Run("downloads binary responses to a file", func() {
+		doer := &MockHTTPClient{Response: newResponse(MIMEApplicationPDF, "%PDF-1.7")}
+		path := filepath.Join(suite.T().TempDir(), "report.pdf")
+		_, err := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodGet).
+			DoWithUnmarshal(context.Background(), FilePath(path))
+		suite.NoError(err)
+		data, err := os.ReadFile(path)
+		suite.NoError(err)
+		suite.Equal("%PDF-1.7", string(data))
+	})
+
+	suite.Run("falls back to the binary decoder for unknown types and binary destinations", func() {
+		path := filepath.Join(suite.T().TempDir(), "archive.tar")
+		for _, contentType := range []string{"application/x-tar", "application/vnd.ms-excel", ""} {
+			doer := &MockHTTPClient{Response: newResponse(contentType, "data")}
+			_, err := NewHTTPClientCall("http://example.com", doer).
+				Method(http.MethodGet).
+				DoWithUnmarshal(context.Background(), FilePath(path))
+			suite.NoError(err, contentType)
+			data, err := os.ReadFile(path)
+			suite.NoError(err)
+			suite.Equal("data", string(data))
+		}
+
+		doer := &MockHTTPClient{Response: newResponse("application/x-tar", "data")}
+		var result []byte
+		_, err := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodGet).
+			DoWithUnmarshal(context.Background(), &result)
+		suite.NoError(err)
+		suite.Equal("data", string(result))
+	})
+
+	suite.Run("returns an error for unsupported content types", func() {
+		doer := &MockHTTPClient{Response: newResponse("application/vnd.custom", "custom")}
+		_, err := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodGet).
+			DoWithUnmarshal(context.Background(), &map[string]string{})
+		suite.EqualError(err, "unsupported content type: application/vnd.custom")
+	})
+}
+
 func TestHTTPClientCallDecoderSuite(t *testing.T) {
 	suite.Run(t, new(HTTPClientCallDecoderSuite))
 }
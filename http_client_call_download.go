@@ -0,0 +1,391 @@
+package client
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Checksum algorithms supported by Download. The names match the Digest header algorithm tokens.
+const (
+	ChecksumSHA256 = "sha-256"
+	ChecksumMD5    = "md5"
+)
+
+// defaultDownloadMaxResumes is the number of times NewDownloadOptions resumes an interrupted download.
+const defaultDownloadMaxResumes = 3
+
+// ErrChecksumMismatch is returned by Download when the downloaded file does not match the expected checksum.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// errRangeNotSatisfiable is returned when the partial file is larger than the resource it resumes.
+var errRangeNotSatisfiable = errors.New("requested range not satisfiable")
+
+// DownloadOptions configures how Download resumes and verifies a file.
+type DownloadOptions struct {
+	// Checksum is the expected hex-encoded checksum of the whole file. It is not verified when empty.
+	Checksum string
+	// ChecksumAlgorithm is the algorithm of Checksum, ChecksumSHA256 when empty.
+	ChecksumAlgorithm string
+	// IfRange is the ETag or Last-Modified validator of the partial file, sent in If-Range when resuming it.
+	IfRange string
+	// MaxResumes is the number of times an interrupted transfer, or each parallel range, is resumed within the call.
+	MaxResumes int
+	// ChunkSize is the size of the ranges downloaded in parallel. The file is split in Concurrency ranges when zero.
+	ChunkSize int64
+	// Concurrency is the number of ranges downloaded in parallel. Values below 2 download a single stream.
+	Concurrency int
+	// Resume continues an existing file from its current size instead of truncating it.
+	Resume bool
+	// VerifyHeaders verifies the file against the Digest and Content-MD5 response headers, when present.
+	VerifyHeaders bool
+}
+
+// NewDownloadOptions creates DownloadOptions that resume interrupted transfers and verify the response headers.
+func NewDownloadOptions() *DownloadOptions {
+	return &DownloadOptions{
+		Checksum:          "",
+		ChecksumAlgorithm: ChecksumSHA256,
+		IfRange:           "",
+		ChunkSize:         0,
+		Concurrency:       1,
+		MaxResumes:        defaultDownloadMaxResumes,
+		Resume:            false,
+		VerifyHeaders:     true,
+	}
+}
+
+// Download streams the response body into the file at path. A transfer interrupted by a transport error is
+// resumed with a Range request, guarded by If-Range so that a modified resource is downloaded again from the
+// start. The method defaults to GET and the identity encoding is requested so that byte offsets match the file; a
+// content coding applied anyway by the server is stored as served and never decoded.
+// With DownloadOptions.Concurrency above 1, a HEAD request probes the size of the resource and its ranges are
+// downloaded in parallel; the returned metadata is then the one of the probe. A nil opts uses NewDownloadOptions.
+func (r *HTTPClientCall) Download(ctx context.Context, path string, opts *DownloadOptions) (*HTTPClientCallResponse, error) {
+	if opts == nil {
+		opts = NewDownloadOptions()
+	}
+	if r.method == "" {
+		r.method = http.MethodGet
+	}
+	defer func() {
+		r.params = nil
+		r.body = nil
+	}()
+	start := time.Now()
+	requestURL := r.constructURL()
+
+	dl, err := newDownload(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer dl.file.Close()
+
+	resp, attempts, err := dl.run(ctx, r)
+	var httpError *HTTPError
+	if errors.As(err, &httpError) {
+		httpClientCallResponse := newHTTPClientCallResponse(resp, requestURL, attempts)
+		httpClientCallResponse.Duration = time.Since(start)
+		return httpClientCallResponse, err
+	}
+	if err == nil {
+		err = dl.verify()
+	}
+	if err != nil {
+		return nil, err
+	}
+	httpClientCallResponse := newHTTPClientCallResponse(resp, requestURL, attempts)
+	httpClientCallResponse.Duration = time.Since(start)
+	return httpClientCallResponse, nil
+}
+
+// download holds the state of a file download across resumed requests.
+type download struct {
+	file      *os.File
+	opts      *DownloadOptions
+	hashes    map[string]hash.Hash
+	header    http.Header
+	validator string
+	offset    int64
+	partial   bool
+}
+
+// newDownload opens the destination file and, when resuming, hashes the bytes already downloaded.
+func newDownload(path string, opts *DownloadOptions) (*download, error) {
+	algorithm := opts.ChecksumAlgorithm
+	if algorithm == "" {
+		algorithm = ChecksumSHA256
+	}
+	if opts.Checksum != "" && newChecksumHash(algorithm) == nil {
+		return nil, fmt.Errorf("unsupported checksum algorithm: %s", algorithm)
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	dl := &download{
+		file:      file,
+		opts:      opts,
+		hashes:    nil,
+		header:    nil,
+		validator: opts.IfRange,
+		offset:    0,
+		partial:   false,
+	}
+	if opts.Checksum != "" || opts.VerifyHeaders {
+		dl.hashes = map[string]hash.Hash{ChecksumSHA256: sha256.New(), ChecksumMD5: md5.New()}
+	}
+	if opts.Resume {
+		err = dl.restore()
+	} else {
+		err = dl.reset()
+	}
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return dl, nil
+}
+
+// restore continues the download from the end of the existing file.
+func (d *download) restore() error {
+	size, err := d.file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if _, err = d.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err = io.Copy(d.hashWriter(io.Discard), io.LimitReader(d.file, size)); err != nil {
+		return err
+	}
+	d.offset = size
+	d.partial = size > 0
+	return nil
+}
+
+// reset truncates the file to download it again from the start.
+func (d *download) reset() error {
+	if err := d.file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := d.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	for _, h := range d.hashes {
+		h.Reset()
+	}
+	d.offset = 0
+	d.partial = false
+	return nil
+}
+
+// hashWriter returns a writer that writes to w and to every checksum hash.
+func (d *download) hashWriter(w io.Writer) io.Writer {
+	writers := []io.Writer{w}
+	for _, h := range d.hashes {
+		writers = append(writers, h)
+	}
+	return io.MultiWriter(writers...)
+}
+
+// run downloads the file in parallel chunks when it is enabled and supported by the server, or as a single
+// stream resumed after transport errors otherwise.
+func (d *download) run(ctx context.Context, r *HTTPClientCall) (*http.Response, int, error) {
+	attempts := 0
+	if d.opts.Concurrency > 1 && !d.opts.Resume {
+		resp, n, size, ok := d.probe(ctx, r)
+		attempts += n
+		if ok {
+			chunkResp, n, err := d.fetchChunks(ctx, r, size)
+			if chunkResp != nil {
+				resp = chunkResp
+			}
+			return resp, attempts + n, err
+		}
+	}
+	for resumes := 0; ; resumes++ {
+		resp, n, resumable, err := d.fetch(ctx, r)
+		attempts += n
+		if err == nil || !resumable || resumes >= d.opts.MaxResumes || ctx.Err() != nil {
+			return resp, attempts, err
+		}
+	}
+}
+
+// fetch sends a request for the remaining bytes and appends the response body to the file. It reports whether
+// the error is a transport error after which the download can be resumed.
+func (d *download) fetch(ctx context.Context, r *HTTPClientCall) (*http.Response, int, bool, error) {
+	req, err := r.newRequest(ctx)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	req.Header.Set(HeaderAcceptEncoding, "identity")
+	if d.offset > 0 {
+		req.Header.Set(HeaderRange, "bytes="+strconv.FormatInt(d.offset, 10)+"-")
+		if d.validator != "" {
+			req.Header.Set(HeaderIfRange, d.validator)
+		}
+	}
+
+	resp, attempts, err := r.doWithRetry(ctx, req)
+	if err != nil {
+		return nil, attempts, DefaultRetryOnError(err), err
+	}
+	defer resp.Body.Close()
+
+	if done, resumable, err := d.accept(r, resp); done || err != nil {
+		return resp, attempts, resumable, err
+	}
+	n, err := io.Copy(d.hashWriter(d.file), resp.Body)
+	d.offset += n
+	if err != nil {
+		return resp, attempts, DefaultRetryOnError(err), err
+	}
+	return resp, attempts, false, nil
+}
+
+// accept checks the response status before its body is appended to the file. It reports whether the file is
+// already complete and whether the error allows the download to be resumed.
+func (d *download) accept(r *HTTPClientCall, resp *http.Response) (bool, bool, error) {
+	switch {
+	case resp.StatusCode == http.StatusPartialContent:
+		rangeStart, _, ok := parseContentRange(resp.Header.Get(HeaderContentRange))
+		if !ok || rangeStart != d.offset {
+			return false, false, fmt.Errorf("unexpected Content-Range: %q", resp.Header.Get(HeaderContentRange))
+		}
+		d.partial = true
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && d.offset > 0:
+		if _, total, _ := parseContentRange(resp.Header.Get(HeaderContentRange)); total == d.offset {
+			return true, false, nil
+		}
+		// The file does not match the resource anymore, download it again from the start.
+		if err := d.reset(); err != nil {
+			return false, false, err
+		}
+		return false, true, errRangeNotSatisfiable
+	case !r.isSuccessStatus(resp.StatusCode):
+		return false, false, r.newHTTPError(resp)
+	default:
+		// The server sent the whole resource, either because it ignores ranges or because it changed.
+		if err := d.reset(); err != nil {
+			return false, false, err
+		}
+	}
+	d.header = resp.Header
+	if validator := rangeValidator(resp.Header); validator != "" {
+		d.validator = validator
+	}
+	return false, false, nil
+}
+
+// verify compares the file with the expected checksum and with the checksums published in the response headers.
+func (d *download) verify() error {
+	if d.opts.Checksum != "" {
+		algorithm := d.opts.ChecksumAlgorithm
+		if algorithm == "" {
+			algorithm = ChecksumSHA256
+		}
+		actual := hex.EncodeToString(d.hashes[algorithm].Sum(nil))
+		if !strings.EqualFold(actual, d.opts.Checksum) {
+			return fmt.Errorf("%w: expected %s %s, got %s", ErrChecksumMismatch, algorithm, d.opts.Checksum, actual)
+		}
+	}
+	if !d.opts.VerifyHeaders || d.header == nil {
+		return nil
+	}
+	expected := parseDigest(d.header.Get(HeaderDigest))
+	// Content-MD5 covers the response body, so it only applies to a download completed in a single response.
+	if contentMD5 := d.header.Get(HeaderContentMD5); contentMD5 != "" && !d.partial {
+		expected[ChecksumMD5] = contentMD5
+	}
+	for algorithm, value := range expected {
+		h, ok := d.hashes[algorithm]
+		if !ok {
+			continue
+		}
+		actual := base64.StdEncoding.EncodeToString(h.Sum(nil))
+		if actual != value {
+			return fmt.Errorf("%w: expected %s %s, got %s", ErrChecksumMismatch, algorithm, value, actual)
+		}
+	}
+	return nil
+}
+
+// newChecksumHash returns a new hash for the checksum algorithm, or nil if it is not supported.
+func newChecksumHash(algorithm string) hash.Hash {
+	switch algorithm {
+	case ChecksumSHA256:
+		return sha256.New()
+	case ChecksumMD5:
+		return md5.New()
+	default:
+		return nil
+	}
+}
+
+// parseDigest parses a Digest header such as "sha-256=X48E9q..., md5=HUXZ..." into base64 checksums by algorithm.
+func parseDigest(value string) map[string]string {
+	digests := make(map[string]string)
+	for _, part := range strings.Split(value, ",") {
+		algorithm, digest, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok {
+			digests[strings.ToLower(algorithm)] = digest
+		}
+	}
+	return digests
+}
+
+// parseContentRange parses a Content-Range header such as "bytes 100-199/200" or "bytes */200". The start and
+// total are -1 when they are unknown.
+func parseContentRange(value string) (start, total int64, ok bool) {
+	rangeSpec, found := strings.CutPrefix(value, "bytes ")
+	if !found {
+		return -1, -1, false
+	}
+	byteRange, size, found := strings.Cut(rangeSpec, "/")
+	if !found {
+		return -1, -1, false
+	}
+	start, total = -1, -1
+	if size != "*" {
+		if total, ok = parseNonNegative(size); !ok {
+			return -1, -1, false
+		}
+	}
+	if byteRange != "*" {
+		first, _, found := strings.Cut(byteRange, "-")
+		if !found {
+			return -1, -1, false
+		}
+		if start, ok = parseNonNegative(first); !ok {
+			return -1, -1, false
+		}
+	}
+	return start, total, true
+}
+
+// parseNonNegative parses a non-negative decimal integer.
+func parseNonNegative(value string) (int64, bool) {
+	n, err := strconv.ParseInt(value, 10, 64)
+	return n, err == nil && n >= 0
+}
+
+// rangeValidator returns the validator to send in If-Range: a strong ETag, or the Last-Modified date.
+func rangeValidator(header http.Header) string {
+	if etag := header.Get(HeaderETag); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return header.Get(HeaderLastModified)
+}
@@ -0,0 +1,164 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// byteRange is an inclusive range of bytes of the downloaded resource.
+type byteRange struct {
+	start int64
+	end   int64
+}
+
+// probe sends a HEAD request and reports the size of the resource when the server supports byte ranges.
+func (d *download) probe(ctx context.Context, r *HTTPClientCall) (*http.Response, int, int64, bool) {
+	req, err := r.newRequest(ctx)
+	if err != nil {
+		return nil, 0, 0, false
+	}
+	req.Method = http.MethodHead
+	req.Header.Set(HeaderAcceptEncoding, "identity")
+
+	resp, attempts, err := r.doWithRetry(ctx, req)
+	if err != nil {
+		return nil, attempts, 0, false
+	}
+	discardResponse(resp)
+	if !r.isSuccessStatus(resp.StatusCode) || resp.ContentLength <= 0 ||
+		!strings.EqualFold(resp.Header.Get(HeaderAcceptRanges), "bytes") {
+		return resp, attempts, 0, false
+	}
+	d.header = resp.Header
+	d.validator = rangeValidator(resp.Header)
+	return resp, attempts, resp.ContentLength, true
+}
+
+// splitRanges splits a resource of the given size into ranges of chunkSize bytes, or into concurrency ranges
+// when chunkSize is zero.
+func splitRanges(size, chunkSize int64, concurrency int) []byteRange {
+	if chunkSize <= 0 {
+		chunkSize = (size + int64(concurrency) - 1) / int64(concurrency)
+	}
+	ranges := make([]byteRange, 0, (size+chunkSize-1)/chunkSize)
+	for start := int64(0); start < size; start += chunkSize {
+		ranges = append(ranges, byteRange{start: start, end: min(start+chunkSize, size) - 1})
+	}
+	return ranges
+}
+
+// fetchChunks downloads the ranges of the resource with Concurrency workers, writing each one at its offset.
+// The first error cancels the remaining ranges; it is returned with its response and the file is truncated,
+// since its size would not be a valid offset to resume from.
+func (d *download) fetchChunks(ctx context.Context, r *HTTPClientCall, size int64) (*http.Response, int, error) {
+	if err := d.file.Truncate(size); err != nil {
+		return nil, 0, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ranges := make(chan byteRange)
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		attempts  int
+		firstResp *http.Response
+		firstErr  error
+	)
+	for range d.opts.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range ranges {
+				resp, n, err := d.fetchChunk(ctx, r, chunk)
+				mu.Lock()
+				attempts += n
+				if err != nil && firstErr == nil {
+					firstResp, firstErr = resp, err
+					cancel()
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, chunk := range splitRanges(size, d.opts.ChunkSize, d.opts.Concurrency) {
+		if ctx.Err() != nil {
+			break
+		}
+		ranges <- chunk
+	}
+	close(ranges)
+	wg.Wait()
+
+	if firstErr != nil {
+		if err := d.reset(); err != nil {
+			return firstResp, attempts, errors.Join(firstErr, err)
+		}
+		return firstResp, attempts, firstErr
+	}
+	if d.hashes == nil {
+		return nil, attempts, nil
+	}
+	// The hashes are computed from the assembled file, since the ranges are written out of order.
+	return nil, attempts, d.restore()
+}
+
+// fetchChunk downloads a range into the file, resuming it after transport errors up to MaxResumes times.
+func (d *download) fetchChunk(ctx context.Context, r *HTTPClientCall, chunk byteRange) (*http.Response, int, error) {
+	attempts := 0
+	for resumes := 0; ; resumes++ {
+		resp, n, resumable, err := d.fetchRange(ctx, r, &chunk)
+		attempts += n
+		if err == nil || !resumable || resumes >= d.opts.MaxResumes || ctx.Err() != nil {
+			return resp, attempts, err
+		}
+	}
+}
+
+// fetchRange sends a request for the range and writes the response body at the range offset, advancing the start
+// of the range by the bytes written. It reports whether the error is a transport error after which the range can
+// be resumed; error responses are retried by the RetryPolicy only.
+func (d *download) fetchRange(ctx context.Context, r *HTTPClientCall, chunk *byteRange) (*http.Response, int, bool, error) {
+	req, err := r.newRequest(ctx)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	req.Header.Set(HeaderAcceptEncoding, "identity")
+	req.Header.Set(HeaderRange, "bytes="+strconv.FormatInt(chunk.start, 10)+"-"+strconv.FormatInt(chunk.end, 10))
+	if d.validator != "" {
+		req.Header.Set(HeaderIfRange, d.validator)
+	}
+
+	resp, attempts, err := r.doWithRetry(ctx, req)
+	if err != nil {
+		return nil, attempts, DefaultRetryOnError(err), err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent {
+		if !r.isSuccessStatus(resp.StatusCode) {
+			return resp, attempts, false, r.newHTTPError(resp)
+		}
+		// The resource changed since the probe or the server ignored the range.
+		return resp, attempts, false, fmt.Errorf("unexpected status code for range request: %d", resp.StatusCode)
+	}
+	if rangeStart, _, ok := parseContentRange(resp.Header.Get(HeaderContentRange)); !ok || rangeStart != chunk.start {
+		return resp, attempts, false, fmt.Errorf("unexpected Content-Range: %q", resp.Header.Get(HeaderContentRange))
+	}
+	length := chunk.end - chunk.start + 1
+	written, err := io.Copy(io.NewOffsetWriter(d.file, chunk.start), io.LimitReader(resp.Body, length))
+	chunk.start += written
+	if err == nil && written < length {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return resp, attempts, DefaultRetryOnError(err), err
+	}
+	return resp, attempts, false, nil
+}
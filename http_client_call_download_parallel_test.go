@@ -0,0 +1,198 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type HTTPClientCallDownloadParallelSuite struct {
+	downloadFixture
+}
+
+func (suite *HTTPClientCallDownloadParallelSuite) parallelOptions() *DownloadOptions {
+	opts := NewDownloadOptions()
+	opts.Concurrency = 4
+	opts.ChunkSize = 1500
+	return opts
+}
+
+func (suite *HTTPClientCallDownloadParallelSuite) rangeHeaders(doer *InterruptingHTTPClient) []string {
+	var ranges []string
+	for _, req := range doer.Requests {
+		if req.Method == http.MethodGet {
+			ranges = append(ranges, req.Header.Get(HeaderRange))
+		}
+	}
+	return ranges
+}
+
+func (suite *HTTPClientCallDownloadParallelSuite) TestDownload_Parallel() {
+	suite.Run("downloads byte ranges concurrently", func() {
+		doer := &InterruptingHTTPClient{Client: suite.server.Client()}
+		opts := suite.parallelOptions()
+		opts.Checksum = suite.sha256Hex()
+		resp, err := NewHTTPClientCall(suite.server.URL, doer).
+			Path("/artifact.bin").
+			Download(context.Background(), suite.path, opts)
+		require.NoError(suite.T(), err)
+		suite.Equal(http.StatusOK, resp.StatusCode)
+		suite.Equal(8, resp.Attempts)
+		suite.Equal(http.MethodHead, doer.Requests[0].Method)
+		suite.ElementsMatch([]string{
+			"bytes=0-1499", "bytes=1500-2999", "bytes=3000-4499", "bytes=4500-5999",
+			"bytes=6000-7499", "bytes=7500-8999", "bytes=9000-9999",
+		}, suite.rangeHeaders(doer))
+		data, err := os.ReadFile(suite.path)
+		suite.NoError(err)
+		suite.Equal(suite.content, data)
+	})
+
+	suite.Run("stores content-encoded resources as served", func() {
+		suite.content = gzipData(strings.Repeat("0123456789", 1000))
+		suite.header.Set(HeaderContentEncoding, "gzip")
+		opts := suite.parallelOptions()
+		opts.ChunkSize = 16
+		opts.Checksum = suite.sha256Hex()
+		c := NewHTTPClient(suite.server.URL, suite.server.Client(), WithResponseDecompression())
+		_, err := c.NewCall().Path("/artifact.bin").Download(context.Background(), suite.path, opts)
+		require.NoError(suite.T(), err)
+		data, err := os.ReadFile(suite.path)
+		suite.NoError(err)
+		suite.Equal(suite.content, data)
+	})
+
+	suite.Run("retries failed ranges on their own", func() {
+		doer := &InterruptingHTTPClient{Client: suite.server.Client(), Interruptions: 2, Limit: 1000}
+		_, err := NewHTTPClientCall(suite.server.URL, doer).
+			Path("/artifact.bin").
+			Download(context.Background(), suite.path, suite.parallelOptions())
+		require.NoError(suite.T(), err)
+		suite.Len(suite.rangeHeaders(doer), 9)
+		for _, req := range doer.Requests[1:] {
+			suite.Equal(`"v1"`, req.Header.Get(HeaderIfRange))
+		}
+		data, err := os.ReadFile(suite.path)
+		suite.NoError(err)
+		suite.Equal(suite.content, data)
+	})
+
+	suite.Run("gives up when a range fails more than MaxResumes times", func() {
+		doer := &InterruptingHTTPClient{Client: suite.server.Client(), Interruptions: 100, Limit: 10}
+		opts := suite.parallelOptions()
+		opts.MaxResumes = 1
+		_, err := NewHTTPClientCall(suite.server.URL, doer).
+			Path("/artifact.bin").
+			Download(context.Background(), suite.path, opts)
+		suite.Error(err)
+	})
+
+	suite.Run("truncates the file when a range fails so that it can be resumed", func() {
+		doer := &InterruptingHTTPClient{Client: suite.server.Client(), Interruptions: 100, Limit: 10}
+		opts := suite.parallelOptions()
+		opts.MaxResumes = 0
+		_, err := NewHTTPClientCall(suite.server.URL, doer).
+			Path("/artifact.bin").
+			Download(context.Background(), suite.path, opts)
+		suite.Error(err)
+		info, err := os.Stat(suite.path)
+		require.NoError(suite.T(), err)
+		suite.Zero(info.Size())
+
+		resume := NewDownloadOptions()
+		resume.Resume = true
+		_, err = NewHTTPClientCall(suite.server.URL, suite.server.Client()).
+			Path("/artifact.bin").
+			Download(context.Background(), suite.path, resume)
+		require.NoError(suite.T(), err)
+		data, err := os.ReadFile(suite.path)
+		suite.NoError(err)
+		suite.Equal(suite.content, data)
+	})
+
+	suite.Run("verifies the Digest header of the probe", func() {
+		sum := sha256.Sum256(suite.content)
+		suite.header.Set(HeaderDigest, "sha-256="+base64.StdEncoding.EncodeToString(sum[:]))
+		_, err := NewHTTPClientCall(suite.server.URL, suite.server.Client()).
+			Path("/artifact.bin").
+			Download(context.Background(), suite.path, suite.parallelOptions())
+		suite.NoError(err)
+
+		suite.header.Set(HeaderDigest, "sha-256="+base64.StdEncoding.EncodeToString(make([]byte, sha256.Size)))
+		_, err = NewHTTPClientCall(suite.server.URL, suite.server.Client()).
+			Path("/artifact.bin").
+			Download(context.Background(), suite.path, suite.parallelOptions())
+		suite.ErrorIs(err, ErrChecksumMismatch)
+	})
+
+	suite.Run("falls back to a single stream without range support", func() {
+		suite.noRanges = true
+		doer := &InterruptingHTTPClient{Client: suite.server.Client()}
+		resp, err := NewHTTPClientCall(suite.server.URL, doer).
+			Path("/artifact.bin").
+			Download(context.Background(), suite.path, suite.parallelOptions())
+		require.NoError(suite.T(), err)
+		suite.Equal(http.StatusOK, resp.StatusCode)
+		suite.Equal([]string{""}, suite.rangeHeaders(doer))
+		data, err := os.ReadFile(suite.path)
+		suite.NoError(err)
+		suite.Equal(suite.content, data)
+	})
+
+	suite.Run("returns an HTTPError for failed ranges", func() {
+		doer := HTTPClientDoerFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Method == http.MethodGet {
+				return newStatusResponse(http.StatusForbidden), nil
+			}
+			return suite.server.Client().Do(req)
+		})
+		resp, err := NewHTTPClientCall(suite.server.URL, doer).
+			Path("/artifact.bin").
+			Download(context.Background(), suite.path, suite.parallelOptions())
+		var httpError *HTTPError
+		suite.ErrorAs(err, &httpError)
+		suite.Equal(http.StatusForbidden, resp.StatusCode)
+	})
+
+	suite.Run("does not resume ranges after error or unexpected responses", func() {
+		for _, status := range []int{http.StatusPreconditionFailed, http.StatusOK} {
+			var gets atomic.Int32
+			doer := HTTPClientDoerFunc(func(req *http.Request) (*http.Response, error) {
+				if req.Method == http.MethodGet {
+					gets.Add(1)
+					return newStatusResponse(status), nil
+				}
+				return suite.server.Client().Do(req)
+			})
+			opts := suite.parallelOptions()
+			opts.ChunkSize = int64(len(suite.content))
+			_, err := NewHTTPClientCall(suite.server.URL, doer).
+				Path("/artifact.bin").
+				Download(context.Background(), suite.path, opts)
+			suite.Error(err)
+			suite.Equal(int32(1), gets.Load(), status)
+		}
+	})
+}
+
+func (suite *HTTPClientCallDownloadParallelSuite) TestSplitRanges() {
+	suite.Run("splits by chunk size", func() {
+		suite.Equal([]byteRange{{start: 0, end: 3}, {start: 4, end: 7}, {start: 8, end: 9}}, splitRanges(10, 4, 2))
+	})
+
+	suite.Run("splits by concurrency without a chunk size", func() {
+		suite.Equal([]byteRange{{start: 0, end: 3}, {start: 4, end: 7}, {start: 8, end: 9}}, splitRanges(10, 0, 3))
+	})
+}
+
+func TestHTTPClientCallDownloadParallelSuite(t *testing.T) {
+	suite.Run(t, new(HTTPClientCallDownloadParallelSuite))
+}
@@ -0,0 +1,324 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// InterruptingHTTPClient sends requests with Client and cuts the body of the first Interruptions responses
+// after Limit bytes.
+type InterruptingHTTPClient struct {
+	Client        *http.Client
+	Requests      []*http.Request
+	Interruptions int
+	Limit         int64
+	mu            sync.Mutex
+}
+
+func (c *InterruptingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	c.Requests = append(c.Requests, req)
+	c.mu.Unlock()
+	resp, err := c.Client.Do(req)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil || c.Interruptions == 0 || req.Method == http.MethodHead {
+		return resp, err
+	}
+	c.Interruptions--
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{
+		Reader: io.MultiReader(io.LimitReader(resp.Body, c.Limit), iotest.ErrReader(io.ErrUnexpectedEOF)),
+		Closer: resp.Body,
+	}
+	return resp, nil
+}
+
+// downloadFixture serves content with range support from a test server for each subtest.
+type downloadFixture struct {
+	suite.Suite
+	content  []byte
+	server   *httptest.Server
+	header   http.Header
+	path     string
+	noRanges bool
+}
+
+type HTTPClientCallDownloadSuite struct {
+	downloadFixture
+}
+
+func (suite *downloadFixture) SetupSubTest() {
+	suite.content = bytes.Repeat([]byte("0123456789"), 1000)
+	suite.header = http.Header{}
+	suite.header.Set(HeaderETag, `"v1"`)
+	suite.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		for key := range suite.header {
+			w.Header().Set(key, suite.header.Get(key))
+		}
+		if suite.noRanges {
+			_, _ = w.Write(suite.content)
+			return
+		}
+		http.ServeContent(w, r, "artifact.bin", time.Time{}, bytes.NewReader(suite.content))
+	}))
+	suite.path = filepath.Join(suite.T().TempDir(), "artifact.bin")
+	suite.noRanges = false
+}
+
+func (suite *downloadFixture) TearDownSubTest() {
+	suite.server.Close()
+}
+
+func (suite *downloadFixture) sha256Hex() string {
+	sum := sha256.Sum256(suite.content)
+	return hex.EncodeToString(sum[:])
+}
+
+func (suite *HTTPClientCallDownloadSuite) TestDownload() {
+	suite.Run("downloads the file and verifies its checksum", func() {
+		opts := NewDownloadOptions()
+		opts.Checksum = suite.sha256Hex()
+		resp, err := NewHTTPClientCall(suite.server.URL, suite.server.Client()).
+			Path("/artifact.bin").
+			Download(context.Background(), suite.path, opts)
+		require.NoError(suite.T(), err)
+		suite.Equal(http.StatusOK, resp.StatusCode)
+		suite.Equal(1, resp.Attempts)
+		data, err := os.ReadFile(suite.path)
+		suite.NoError(err)
+		suite.Equal(suite.content, data)
+	})
+
+	suite.Run("resumes an interrupted transfer with Range and If-Range", func() {
+		doer := &InterruptingHTTPClient{Client: suite.server.Client(), Interruptions: 2, Limit: 3000}
+		resp, err := NewHTTPClientCall(suite.server.URL, doer).
+			Path("/artifact.bin").
+			Download(context.Background(), suite.path, nil)
+		require.NoError(suite.T(), err)
+		suite.Equal(http.StatusPartialContent, resp.StatusCode)
+		suite.Equal(3, resp.Attempts)
+		require.Len(suite.T(), doer.Requests, 3)
+		suite.Empty(doer.Requests[0].Header.Get(HeaderRange))
+		suite.Equal("bytes=3000-", doer.Requests[1].Header.Get(HeaderRange))
+		suite.Equal("bytes=6000-", doer.Requests[2].Header.Get(HeaderRange))
+		suite.Equal(`"v1"`, doer.Requests[2].Header.Get(HeaderIfRange))
+		suite.Equal("identity", doer.Requests[2].Header.Get(HeaderAcceptEncoding))
+		data, err := os.ReadFile(suite.path)
+		suite.NoError(err)
+		suite.Equal(suite.content, data)
+	})
+
+	suite.Run("gives up after MaxResumes interruptions", func() {
+		doer := &InterruptingHTTPClient{Client: suite.server.Client(), Interruptions: 3, Limit: 100}
+		opts := NewDownloadOptions()
+		opts.MaxResumes = 1
+		_, err := NewHTTPClientCall(suite.server.URL, doer).
+			Path("/artifact.bin").
+			Download(context.Background(), suite.path, opts)
+		suite.ErrorIs(err, io.ErrUnexpectedEOF)
+		suite.Len(doer.Requests, 2)
+		info, err := os.Stat(suite.path)
+		suite.NoError(err)
+		suite.Equal(int64(200), info.Size())
+	})
+
+	suite.Run("resumes an existing partial file", func() {
+		require.NoError(suite.T(), os.WriteFile(suite.path, suite.content[:4000], 0o600))
+		doer := &InterruptingHTTPClient{Client: suite.server.Client()}
+		opts := NewDownloadOptions()
+		opts.Resume = true
+		opts.IfRange = `"v1"`
+		opts.Checksum = suite.sha256Hex()
+		resp, err := NewHTTPClientCall(suite.server.URL, doer).
+			Path("/artifact.bin").
+			Download(context.Background(), suite.path, opts)
+		require.NoError(suite.T(), err)
+		suite.Equal(http.StatusPartialContent, resp.StatusCode)
+		suite.Equal("bytes=4000-", doer.Requests[0].Header.Get(HeaderRange))
+		data, err := os.ReadFile(suite.path)
+		suite.NoError(err)
+		suite.Equal(suite.content, data)
+	})
+
+	suite.Run("stores content-encoded resources as served", func() {
+		suite.content = gzipData(strings.Repeat("0123456789", 1000))
+		suite.header.Set(HeaderContentEncoding, "gzip")
+		require.NoError(suite.T(), os.WriteFile(suite.path, suite.content[:20], 0o600))
+		opts := NewDownloadOptions()
+		opts.Resume = true
+		opts.Checksum = suite.sha256Hex()
+		c := NewHTTPClient(suite.server.URL, suite.server.Client(), WithResponseDecompression())
+		resp, err := c.NewCall().Path("/artifact.bin").Download(context.Background(), suite.path, opts)
+		require.NoError(suite.T(), err)
+		suite.Equal(http.StatusPartialContent, resp.StatusCode)
+		data, err := os.ReadFile(suite.path)
+		suite.NoError(err)
+		suite.Equal(suite.content, data)
+	})
+
+	suite.Run("downloads the whole file again when the validator does not match", func() {
+		require.NoError(suite.T(), os.WriteFile(suite.path, []byte("stale"), 0o600))
+		opts := NewDownloadOptions()
+		opts.Resume = true
+		opts.IfRange = `"v0"`
+		resp, err := NewHTTPClientCall(suite.server.URL, suite.server.Client()).
+			Path("/artifact.bin").
+			Download(context.Background(), suite.path, opts)
+		require.NoError(suite.T(), err)
+		suite.Equal(http.StatusOK, resp.StatusCode)
+		data, err := os.ReadFile(suite.path)
+		suite.NoError(err)
+		suite.Equal(suite.content, data)
+	})
+
+	suite.Run("treats 416 for a complete file as done", func() {
+		require.NoError(suite.T(), os.WriteFile(suite.path, suite.content, 0o600))
+		opts := NewDownloadOptions()
+		opts.Resume = true
+		opts.Checksum = suite.sha256Hex()
+		resp, err := NewHTTPClientCall(suite.server.URL, suite.server.Client()).
+			Path("/artifact.bin").
+			Download(context.Background(), suite.path, opts)
+		require.NoError(suite.T(), err)
+		suite.Equal(http.StatusRequestedRangeNotSatisfiable, resp.StatusCode)
+		data, err := os.ReadFile(suite.path)
+		suite.NoError(err)
+		suite.Equal(suite.content, data)
+	})
+
+	suite.Run("restarts when the partial file is larger than the resource", func() {
+		require.NoError(suite.T(), os.WriteFile(suite.path, append(bytes.Clone(suite.content), "extra"...), 0o600))
+		opts := NewDownloadOptions()
+		opts.Resume = true
+		_, err := NewHTTPClientCall(suite.server.URL, suite.server.Client()).
+			Path("/artifact.bin").
+			Download(context.Background(), suite.path, opts)
+		require.NoError(suite.T(), err)
+		data, err := os.ReadFile(suite.path)
+		suite.NoError(err)
+		suite.Equal(suite.content, data)
+	})
+
+	suite.Run("returns ErrChecksumMismatch for a wrong checksum", func() {
+		opts := NewDownloadOptions()
+		opts.Checksum = hex.EncodeToString(make([]byte, sha256.Size))
+		_, err := NewHTTPClientCall(suite.server.URL, suite.server.Client()).
+			Path("/artifact.bin").
+			Download(context.Background(), suite.path, opts)
+		suite.ErrorIs(err, ErrChecksumMismatch)
+	})
+
+	suite.Run("rejects unsupported checksum algorithms", func() {
+		opts := NewDownloadOptions()
+		opts.Checksum = "abc"
+		opts.ChecksumAlgorithm = "crc32"
+		_, err := NewHTTPClientCall(suite.server.URL, suite.server.Client()).
+			Path("/artifact.bin").
+			Download(context.Background(), suite.path, opts)
+		suite.EqualError(err, "unsupported checksum algorithm: crc32")
+	})
+
+	suite.Run("returns an HTTPError for error responses", func() {
+		resp, err := NewHTTPClientCall(suite.server.URL, suite.server.Client()).
+			Method(http.MethodPost).
+			Path("/artifact.bin").
+			Download(context.Background(), suite.path, nil)
+		var httpError *HTTPError
+		suite.ErrorAs(err, &httpError)
+		suite.Equal(http.StatusMethodNotAllowed, resp.StatusCode)
+	})
+}
+
+func (suite *HTTPClientCallDownloadSuite) TestDownload_VerifyHeaders() {
+	md5Sum := md5.Sum(suite.content)
+	sha256Sum := sha256.Sum256(suite.content)
+
+	suite.Run("verifies the Digest header across resumed requests", func() {
+		suite.header.Set(HeaderDigest, "SHA-256="+base64.StdEncoding.EncodeToString(sha256Sum[:]))
+		doer := &InterruptingHTTPClient{Client: suite.server.Client(), Interruptions: 1, Limit: 500}
+		_, err := NewHTTPClientCall(suite.server.URL, doer).
+			Path("/artifact.bin").
+			Download(context.Background(), suite.path, nil)
+		suite.NoError(err)
+	})
+
+	suite.Run("verifies the Content-MD5 header", func() {
+		suite.header.Set(HeaderContentMD5, base64.StdEncoding.EncodeToString(md5Sum[:]))
+		_, err := NewHTTPClientCall(suite.server.URL, suite.server.Client()).
+			Path("/artifact.bin").
+			Download(context.Background(), suite.path, nil)
+		suite.NoError(err)
+	})
+
+	suite.Run("returns ErrChecksumMismatch when the Digest header does not match", func() {
+		suite.header.Set(HeaderDigest, "md5="+base64.StdEncoding.EncodeToString(make([]byte, md5.Size)))
+		_, err := NewHTTPClientCall(suite.server.URL, suite.server.Client()).
+			Path("/artifact.bin").
+			Download(context.Background(), suite.path, nil)
+		suite.ErrorIs(err, ErrChecksumMismatch)
+	})
+
+	suite.Run("skips header verification when disabled", func() {
+		suite.header.Set(HeaderDigest, "md5="+base64.StdEncoding.EncodeToString(make([]byte, md5.Size)))
+		opts := NewDownloadOptions()
+		opts.VerifyHeaders = false
+		_, err := NewHTTPClientCall(suite.server.URL, suite.server.Client()).
+			Path("/artifact.bin").
+			Download(context.Background(), suite.path, opts)
+		suite.NoError(err)
+	})
+}
+
+func (suite *HTTPClientCallDownloadSuite) TestParseContentRange() {
+	suite.Run("parses byte ranges and unknown lengths", func() {
+		start, total, ok := parseContentRange("bytes 100-199/200")
+		suite.True(ok)
+		suite.Equal(int64(100), start)
+		suite.Equal(int64(200), total)
+
+		start, total, ok = parseContentRange("bytes */200")
+		suite.True(ok)
+		suite.Equal(int64(-1), start)
+		suite.Equal(int64(200), total)
+
+		start, total, ok = parseContentRange("bytes 0-99/*")
+		suite.True(ok)
+		suite.Equal(int64(0), start)
+		suite.Equal(int64(-1), total)
+	})
+
+	suite.Run("rejects invalid values", func() {
+		for _, value := range []string{"", "items 0-1/2", "bytes 0-1", "bytes x-1/2", "bytes 0-1/x", "bytes 5/10"} {
+			_, _, ok := parseContentRange(value)
+			suite.False(ok, value)
+		}
+	})
+}
+
+func TestHTTPClientCallDownloadSuite(t *testing.T) {
+	suite.Run(t, new(HTTPClientCallDownloadSuite))
+}
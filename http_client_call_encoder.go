@@ -0,0 +1,222 @@
+package client
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// RequestEncoder is an interface for encoding an HTTP request body.
+type RequestEncoder interface {
+	// ContentType returns the Content-Type set on the request when it does not define one.
+	ContentType() string
+	// Encode writes the encoded body to the writer.
+	Encode(io.Writer, any) error
+}
+
+// JSONRequestEncoder encodes request bodies as JSON.
+type JSONRequestEncoder struct{}
+
+// ContentType returns application/json.
+func (e *JSONRequestEncoder) ContentType() string {
+	return MIMEApplicationJSON
+}
+
+// Encode encodes the body as JSON.
+func (e *JSONRequestEncoder) Encode(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+// XMLRequestEncoder encodes request bodies as XML.
+type XMLRequestEncoder struct{}
+
+// ContentType returns application/xml with the UTF-8 charset.
+func (e *XMLRequestEncoder) ContentType() string {
+	return MIMEApplicationXMLCharsetUTF8
+}
+
+// Encode encodes the body as XML.
+func (e *XMLRequestEncoder) Encode(w io.Writer, v any) error {
+	return xml.NewEncoder(w).Encode(v)
+}
+
+// FormRequestEncoder encodes url.Values, map[string]string and map[string][]string bodies as a URL-encoded form.
+type FormRequestEncoder struct{}
+
+// ContentType returns application/x-www-form-urlencoded.
+func (e *FormRequestEncoder) ContentType() string {
+	return MIMEApplicationForm
+}
+
+// Encode encodes the body as a URL-encoded form.
+func (e *FormRequestEncoder) Encode(w io.Writer, v any) error {
+	var values url.Values
+	switch body := v.(type) {
+	case url.Values:
+		values = body
+	case map[string][]string:
+		values = body
+	case map[string]string:
+		values = make(url.Values, len(body))
+		for key, value := range body {
+			values.Set(key, value)
+		}
+	default:
+		return fmt.Errorf("FormRequestEncoder: unsupported type %T", v)
+	}
+	_, err := io.WriteString(w, values.Encode())
+	return err
+}
+
+// TextRequestEncoder encodes string, []byte and fmt.Stringer bodies as plain text.
+type TextRequestEncoder struct{}
+
+// ContentType returns text/plain with the UTF-8 charset.
+func (e *TextRequestEncoder) ContentType() string {
+	return MIMETextPlainCharsetUTF8
+}
+
+// Encode writes the body as plain text.
+func (e *TextRequestEncoder) Encode(w io.Writer, v any) error {
+	var err error
+	switch body := v.(type) {
+	case string:
+		_, err = io.WriteString(w, body)
+	case []byte:
+		_, err = w.Write(body)
+	case fmt.Stringer:
+		_, err = io.WriteString(w, body.String())
+	default:
+		return fmt.Errorf("TextRequestEncoder: unsupported type %T", v)
+	}
+	return err
+}
+
+// RawRequestEncoder passes []byte and io.Reader bodies through unchanged.
+type RawRequestEncoder struct{}
+
+// ContentType returns application/octet-stream.
+func (e *RawRequestEncoder) ContentType() string {
+	return MIMEOctetStream
+}
+
+// Encode copies the body to the writer.
+func (e *RawRequestEncoder) Encode(w io.Writer, v any) error {
+	var err error
+	switch body := v.(type) {
+	case []byte:
+		_, err = w.Write(body)
+	case io.Reader:
+		_, err = io.Copy(w, body)
+	default:
+		return fmt.Errorf("RawRequestEncoder: unsupported type %T", v)
+	}
+	return err
+}
+
+// EncoderRegistry maps media types to request encoders. It is safe for concurrent use.
+type EncoderRegistry struct {
+	encoders map[string]RequestEncoder
+	mu       sync.RWMutex
+}
+
+// NewEncoderRegistry creates an EncoderRegistry with the built-in encoders for JSON, XML, forms, text and raw bytes.
+func NewEncoderRegistry() *EncoderRegistry {
+	registry := &EncoderRegistry{
+		encoders: make(map[string]RequestEncoder),
+		mu:       sync.RWMutex{},
+	}
+	registry.Register(MIMEApplicationJSON, &JSONRequestEncoder{})
+	registry.Register(MIMEApplicationXML, &XMLRequestEncoder{})
+	registry.Register(MIMETextXML, &XMLRequestEncoder{})
+	registry.Register(MIMEApplicationForm, &FormRequestEncoder{})
+	registry.Register(MIMETextPlain, &TextRequestEncoder{})
+	registry.Register(MIMEOctetStream, &RawRequestEncoder{})
+	return registry
+}
+
+// Register sets the encoder used for the given media type.
+func (e *EncoderRegistry) Register(mediaType string, encoder RequestEncoder) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.encoders[strings.ToLower(mediaType)] = encoder
+}
+
+// Lookup returns the encoder registered for the media type of the given Content-Type, or nil if there is none.
+// Media types with a +json or +xml structured syntax suffix fall back to the JSON and XML encoders.
+func (e *EncoderRegistry) Lookup(contentType string) RequestEncoder {
+	mediaType, ok := parseMediaType(contentType)
+	if !ok {
+		return nil
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if encoder, ok := e.encoders[mediaType]; ok {
+		return encoder
+	}
+	switch {
+	case strings.HasSuffix(mediaType, "+json"):
+		return e.encoders[MIMEApplicationJSON]
+	case strings.HasSuffix(mediaType, "+xml"):
+		return e.encoders[MIMEApplicationXML]
+	default:
+		return nil
+	}
+}
+
+// Clone returns a copy of the registry.
+func (e *EncoderRegistry) Clone() *EncoderRegistry {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	encoders := make(map[string]RequestEncoder, len(e.encoders))
+	for mediaType, encoder := range e.encoders {
+		encoders[mediaType] = encoder
+	}
+	return &EncoderRegistry{
+		encoders: encoders,
+		mu:       sync.RWMutex{},
+	}
+}
+
+// defaultEncoders is the registry used by requests that do not set their own.
+var defaultEncoders = NewEncoderRegistry()
+
+// Encoder sets the encoder for the HTTP request body, regardless of its Content-Type.
+func (r *HTTPClientCall) Encoder(encoder RequestEncoder) *HTTPClientCall {
+	r.encoder = encoder
+	return r
+}
+
+// WithRequestEncoder registers an encoder for a media type on the client, on top of the built-in encoders.
+func WithRequestEncoder(mediaType string, encoder RequestEncoder) ClientOption {
+	return func(c *HTTPClient) {
+		if c.encoders == nil {
+			c.encoders = defaultEncoders.Clone()
+		}
+		c.encoders.Register(mediaType, encoder)
+	}
+}
+
+// selectEncoder selects the request encoder: the explicit encoder first, then the one registered for the
+// Content-Type header, falling back to JSON.
+func (r *HTTPClientCall) selectEncoder() RequestEncoder {
+	if r.encoder != nil {
+		return r.encoder
+	}
+	contentType := r.headerValue(HeaderContentType)
+	if contentType == "" {
+		return &JSONRequestEncoder{}
+	}
+	encoders := r.encoders
+	if encoders == nil {
+		encoders = defaultEncoders
+	}
+	if encoder := encoders.Lookup(contentType); encoder != nil {
+		return encoder
+	}
+	return &JSONRequestEncoder{}
+}
@@ -0,0 +1,174 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type encoderItem struct {
+	XMLName xml.Name `xml:"item"`
+	Name    string   `xml:"name"`
+}
+
+type stringer struct{}
+
+func (stringer) String() string { return "stringer" }
+
+type HTTPClientCallEncoderSuite struct {
+	suite.Suite
+}
+
+func (suite *HTTPClientCallEncoderSuite) encode(encoder RequestEncoder, v any) (string, error) {
+	var buf bytes.Buffer
+	err := encoder.Encode(&buf, v)
+	return buf.String(), err
+}
+
+func (suite *HTTPClientCallEncoderSuite) TestEncoders() {
+	suite.Run("JSONRequestEncoder", func() {
+		body, err := suite.encode(&JSONRequestEncoder{}, map[string]string{"key": "value"})
+		require.NoError(suite.T(), err)
+		suite.JSONEq(`{"key":"value"}`, body)
+		suite.Equal(MIMEApplicationJSON, (&JSONRequestEncoder{}).ContentType())
+	})
+
+	suite.Run("XMLRequestEncoder", func() {
+		body, err := suite.encode(&XMLRequestEncoder{}, encoderItem{Name: "test"})
+		require.NoError(suite.T(), err)
+		suite.Equal("<item><name>test</name></item>", body)
+		suite.Equal(MIMEApplicationXMLCharsetUTF8, (&XMLRequestEncoder{}).ContentType())
+	})
+
+	suite.Run("FormRequestEncoder", func() {
+		for _, v := range []any{
+			url.Values{"b": []string{"2"}, "a": []string{"1 2"}},
+			map[string][]string{"b": {"2"}, "a": {"1 2"}},
+			map[string]string{"b": "2", "a": "1 2"},
+		} {
+			body, err := suite.encode(&FormRequestEncoder{}, v)
+			require.NoError(suite.T(), err)
+			suite.Equal("a=1+2&b=2", body)
+		}
+		_, err := suite.encode(&FormRequestEncoder{}, 1)
+		suite.EqualError(err, "FormRequestEncoder: unsupported type int")
+	})
+
+	suite.Run("TextRequestEncoder", func() {
+		for v, expected := range map[any]string{"text": "text", stringer{}: "stringer"} {
+			body, err := suite.encode(&TextRequestEncoder{}, v)
+			require.NoError(suite.T(), err)
+			suite.Equal(expected, body)
+		}
+		body, err := suite.encode(&TextRequestEncoder{}, []byte("bytes"))
+		require.NoError(suite.T(), err)
+		suite.Equal("bytes", body)
+		_, err = suite.encode(&TextRequestEncoder{}, 1)
+		suite.EqualError(err, "TextRequestEncoder: unsupported type int")
+	})
+
+	suite.Run("RawRequestEncoder", func() {
+		body, err := suite.encode(&RawRequestEncoder{}, []byte{0x01, 0x02})
+		require.NoError(suite.T(), err)
+		suite.Equal("\x01\x02", body)
+		body, err = suite.encode(&RawRequestEncoder{}, strings.NewReader("reader"))
+		require.NoError(suite.T(), err)
+		suite.Equal("reader", body)
+		_, err = suite.encode(&RawRequestEncoder{}, "string")
+		suite.EqualError(err, "RawRequestEncoder: unsupported type string")
+	})
+}
+
+func (suite *HTTPClientCallEncoderSuite) TestEncoderRegistry() {
+	suite.Run("looks up encoders by media type", func() {
+		registry := NewEncoderRegistry()
+		suite.IsType(&JSONRequestEncoder{}, registry.Lookup(MIMEApplicationJSONCharsetUTF8))
+		suite.IsType(&XMLRequestEncoder{}, registry.Lookup(MIMETextXML))
+		suite.IsType(&FormRequestEncoder{}, registry.Lookup(MIMEApplicationForm))
+		suite.IsType(&TextRequestEncoder{}, registry.Lookup("Text/Plain; charset=UTF-8"))
+		suite.IsType(&RawRequestEncoder{}, registry.Lookup(MIMEOctetStream))
+		suite.IsType(&JSONRequestEncoder{}, registry.Lookup("application/vnd.api+json"))
+		suite.IsType(&XMLRequestEncoder{}, registry.Lookup("application/atom+xml"))
+		suite.IsType(&XMLRequestEncoder{}, registry.Lookup("application/xml; charset"))
+		suite.Nil(registry.Lookup("application/unknown"))
+		suite.Nil(registry.Lookup("invalid content type;"))
+	})
+
+	suite.Run("clones without sharing registrations", func() {
+		registry := NewEncoderRegistry()
+		clone := registry.Clone()
+		clone.Register("application/vnd.custom", &TextRequestEncoder{})
+		suite.NotNil(clone.Lookup("application/vnd.custom"))
+		suite.Nil(registry.Lookup("application/vnd.custom"))
+	})
+}
+
+func (suite *HTTPClientCallEncoderSuite) TestSetRequestBody() {
+	suite.Run("selects the encoder from the Content-Type header", func() {
+		doer := &RecordingHTTPClient{}
+		_, err := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodPost).
+			Headers(http.Header{HeaderContentType: []string{MIMEApplicationForm}}).
+			Body(map[string]string{"key": "value"}).
+			Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Equal("key=value", doer.Bodies[0])
+		suite.Equal([]string{MIMEApplicationForm}, doer.Requests[0].Header.Values(HeaderContentType))
+	})
+
+	suite.Run("uses the explicit encoder and sets its Content-Type", func() {
+		doer := &RecordingHTTPClient{}
+		_, err := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodPost).
+			Encoder(&XMLRequestEncoder{}).
+			Body(encoderItem{Name: "test"}).
+			Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Equal("<item><name>test</name></item>", doer.Bodies[0])
+		suite.Equal(MIMEApplicationXMLCharsetUTF8, doer.Requests[0].Header.Get(HeaderContentType))
+	})
+
+	suite.Run("falls back to JSON for unknown content types", func() {
+		doer := &RecordingHTTPClient{}
+		_, err := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodPost).
+			Headers(http.Header{HeaderContentType: []string{"application/vnd.custom"}}).
+			Body(map[string]string{"key": "value"}).
+			Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.JSONEq(`{"key":"value"}`, doer.Bodies[0])
+		suite.Equal("application/vnd.custom", doer.Requests[0].Header.Get(HeaderContentType))
+	})
+
+	suite.Run("uses the encoders registered on the client", func() {
+		doer := &RecordingHTTPClient{}
+		c := NewHTTPClient("http://example.com", doer,
+			WithRequestEncoder("application/vnd.custom", &TextRequestEncoder{}),
+			WithDefaultHeaders(http.Header{HeaderContentType: []string{"application/vnd.custom"}}),
+		)
+		_, err := c.NewCall().Method(http.MethodPost).Body("custom").Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Equal("custom", doer.Bodies[0])
+		suite.Nil(defaultEncoders.Lookup("application/vnd.custom"))
+	})
+
+	suite.Run("returns encoding errors", func() {
+		_, err := NewHTTPClientCall("http://example.com", &RecordingHTTPClient{}).
+			Method(http.MethodPost).
+			Encoder(&FormRequestEncoder{}).
+			Body(1).
+			Do(context.Background())
+		suite.EqualError(err, "FormRequestEncoder: unsupported type int")
+	})
+}
+
+func TestHTTPClientCallEncoderSuite(t *testing.T) {
+	suite.Run(t, new(HTTPClientCallEncoderSuite))
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Constants for the default success status range and the error body limits.
+const (
+	defaultSuccessStatusMin = http.StatusOK
+	defaultSuccessStatusMax = 299
+	maxErrorBodySize        = 64 << 10
+	maxErrorMessageSize     = 512
+)
+
+// HTTPError is returned by DoWithUnmarshal when the response status code is outside the success range.
+type HTTPError struct {
+	// Header holds the response headers.
+	Header http.Header
+	// ErrorBody holds the decoded error body when an error body destination was set and decoding succeeded.
+	ErrorBody any
+	// Problem holds the decoded problem details when the response content type is application/problem+json.
+	Problem *ProblemDetails
+	// Body holds the raw response body, truncated to 64 KiB.
+	Body []byte
+	// StatusCode is the response status code.
+	StatusCode int
+}
+
+// Error returns the status code and a snippet of the response body.
+func (e *HTTPError) Error() string {
+	snippet := e.Body
+	if len(snippet) > maxErrorMessageSize {
+		snippet = snippet[:maxErrorMessageSize]
+	}
+	if len(snippet) == 0 {
+		return fmt.Sprintf("unexpected status code %d", e.StatusCode)
+	}
+	return fmt.Sprintf("unexpected status code %d: %s", e.StatusCode, snippet)
+}
+
+// Unwrap returns the problem details of the response, if any, so that errors.As can match *ProblemDetails.
+func (e *HTTPError) Unwrap() error {
+	if e.Problem == nil {
+		return nil
+	}
+	return e.Problem
+}
+
+// SuccessStatus sets the inclusive range of status codes considered successful. It defaults to 200-299.
+func (r *HTTPClientCall) SuccessStatus(minStatus, maxStatus int) *HTTPClientCall {
+	r.successStatusMin = minStatus
+	r.successStatusMax = maxStatus
+	return r
+}
+
+// ErrorBody sets the destination the response body is decoded into when the status code is not successful.
+func (r *HTTPClientCall) ErrorBody(errorBody any) *HTTPClientCall {
+	r.errorBody = errorBody
+	return r
+}
+
+// WithSuccessStatus sets the inclusive range of status codes considered successful for every request.
+func WithSuccessStatus(minStatus, maxStatus int) ClientOption {
+	return func(c *HTTPClient) {
+		c.successStatusMin = minStatus
+		c.successStatusMax = maxStatus
+	}
+}
+
+// isSuccessStatus reports whether the status code is within the success range.
+func (r *HTTPClientCall) isSuccessStatus(statusCode int) bool {
+	if r.successStatusMin == 0 && r.successStatusMax == 0 {
+		return statusCode >= defaultSuccessStatusMin && statusCode <= defaultSuccessStatusMax
+	}
+	return statusCode >= r.successStatusMin && statusCode <= r.successStatusMax
+}
+
+// newHTTPError reads the response body and builds an HTTPError, decoding problem details and the error body.
+func (r *HTTPClientCall) newHTTPError(resp *http.Response) error {
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if err != nil {
+		return err
+	}
+
+	httpError := &HTTPError{
+		Header:     resp.Header,
+		ErrorBody:  nil,
+		Problem:    nil,
+		Body:       data,
+		StatusCode: resp.StatusCode,
+	}
+	if len(data) == 0 {
+		return httpError
+	}
+	contentType := resp.Header.Get(HeaderContentType)
+	if isProblemJSON(contentType) {
+		var problem ProblemDetails
+		if json.Unmarshal(data, &problem) == nil {
+			httpError.Problem = &problem
+		}
+	}
+	if r.errorBody == nil {
+		return httpError
+	}
+	decoder := r.lookupDecoder(contentType)
+	if decoder != nil && decoder.Decode(bytes.NewReader(data), r.errorBody) == nil {
+		httpError.ErrorBody = r.errorBody
+	}
+	return httpError
+}
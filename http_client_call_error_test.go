@@ -0,0 +1,120 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type HTTPClientCallErrorSuite struct {
+	suite.Suite
+	client *MockHTTPClient
+}
+
+func (suite *HTTPClientCallErrorSuite) SetupTest() {
+	suite.client = &MockHTTPClient{}
+}
+
+func (suite *HTTPClientCallErrorSuite) mockResponse(statusCode int, contentType, body string) {
+	suite.client.Response = &http.Response{
+		StatusCode: statusCode,
+		Header:     http.Header{HeaderContentType: []string{contentType}},
+		Body:       io.NopCloser(bytes.NewBufferString(body)),
+	}
+}
+
+func (suite *HTTPClientCallErrorSuite) TestHTTPError_Error() {
+	suite.Run("includes the status code and body snippet", func() {
+		err := &HTTPError{StatusCode: http.StatusNotFound, Body: []byte("not found")}
+		suite.EqualError(err, "unexpected status code 404: not found")
+	})
+
+	suite.Run("omits an empty body", func() {
+		err := &HTTPError{StatusCode: http.StatusInternalServerError}
+		suite.EqualError(err, "unexpected status code 500")
+	})
+
+	suite.Run("truncates long bodies", func() {
+		err := &HTTPError{StatusCode: http.StatusInternalServerError, Body: bytes.Repeat([]byte("a"), 2*maxErrorMessageSize)}
+		suite.Equal("unexpected status code 500: "+strings.Repeat("a", maxErrorMessageSize), err.Error())
+	})
+}
+
+func (suite *HTTPClientCallErrorSuite) TestDoWithUnmarshal_ErrorStatus() {
+	suite.Run("returns an HTTPError with the decoded error body", func() {
+		suite.mockResponse(http.StatusInternalServerError, MIMEApplicationJSON, `{"code":"internal","message":"boom"}`)
+
+		var success map[string]string
+		var failure apiError
+		resp, err := NewHTTPClientCall("http://example.com", suite.client).
+			Method(http.MethodGet).
+			ErrorBody(&failure).
+			DoWithUnmarshal(context.Background(), &success)
+
+		var httpError *HTTPError
+		require.True(suite.T(), errors.As(err, &httpError))
+		suite.Equal(http.StatusInternalServerError, httpError.StatusCode)
+		suite.Equal(MIMEApplicationJSON, httpError.Header.Get(HeaderContentType))
+		suite.JSONEq(`{"code":"internal","message":"boom"}`, string(httpError.Body))
+		suite.Equal(&apiError{Code: "internal", Message: "boom"}, httpError.ErrorBody)
+		suite.Equal(http.StatusInternalServerError, resp.StatusCode)
+		suite.Nil(success)
+	})
+
+	suite.Run("keeps the raw body when the error body cannot be decoded", func() {
+		suite.mockResponse(http.StatusBadGateway, MIMETextHTML, "<html>bad gateway</html>")
+
+		var failure apiError
+		_, err := NewHTTPClientCall("http://example.com", suite.client).
+			Method(http.MethodGet).
+			ErrorBody(&failure).
+			DoWithUnmarshal(context.Background(), &failure)
+
+		var httpError *HTTPError
+		require.True(suite.T(), errors.As(err, &httpError))
+		suite.Equal("<html>bad gateway</html>", string(httpError.Body))
+		suite.Nil(httpError.ErrorBody)
+	})
+
+	suite.Run("honors a custom success status range", func() {
+		suite.mockResponse(http.StatusNotFound, MIMEApplicationJSON, `{"key":"value"}`)
+
+		var success map[string]string
+		resp, err := NewHTTPClientCall("http://example.com", suite.client).
+			Method(http.MethodGet).
+			SuccessStatus(http.StatusOK, http.StatusNotFound).
+			DoWithUnmarshal(context.Background(), &success)
+		require.NoError(suite.T(), err)
+		suite.Equal(http.StatusNotFound, resp.StatusCode)
+		suite.Equal("value", success["key"])
+	})
+
+	suite.Run("inherits the success status range from the client", func() {
+		suite.mockResponse(http.StatusOK, MIMEApplicationJSON, `{"key":"value"}`)
+
+		var success map[string]string
+		_, err := NewHTTPClient("http://example.com", suite.client, WithSuccessStatus(http.StatusCreated, http.StatusCreated)).
+			NewCall().
+			Method(http.MethodGet).
+			DoWithUnmarshal(context.Background(), &success)
+
+		var httpError *HTTPError
+		suite.ErrorAs(err, &httpError)
+	})
+}
+
+func TestHTTPClientCallErrorSuite(t *testing.T) {
+	suite.Run(t, new(HTTPClientCallErrorSuite))
+}
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Get sends a GET request with the builder and decodes the response body into a value of type T.
+func Get[T any](ctx context.Context, call *HTTPClientCall) (T, *HTTPClientCallResponse, error) {
+	return doWithResult[T](ctx, call.Method(http.MethodGet))
+}
+
+// Post sends a POST request with the given body and decodes the response body into a value of type T.
+func Post[T any](ctx context.Context, call *HTTPClientCall, body any) (T, *HTTPClientCallResponse, error) {
+	return doWithResult[T](ctx, call.Method(http.MethodPost).Body(body))
+}
+
+// Put sends a PUT request with the given body and decodes the response body into a value of type T.
+func Put[T any](ctx context.Context, call *HTTPClientCall, body any) (T, *HTTPClientCallResponse, error) {
+	return doWithResult[T](ctx, call.Method(http.MethodPut).Body(body))
+}
+
+// Patch sends a PATCH request with the given body and decodes the response body into a value of type T.
+func Patch[T any](ctx context.Context, call *HTTPClientCall, body any) (T, *HTTPClientCallResponse, error) {
+	return doWithResult[T](ctx, call.Method(http.MethodPatch).Body(body))
+}
+
+// Delete sends a DELETE request with the builder and decodes the response body into a value of type T.
+func Delete[T any](ctx context.Context, call *HTTPClientCall) (T, *HTTPClientCallResponse, error) {
+	return doWithResult[T](ctx, call.Method(http.MethodDelete))
+}
+
+// doWithResult executes the HTTP request and returns the decoded response body as a value of type T.
+func doWithResult[T any](ctx context.Context, call *HTTPClientCall) (T, *HTTPClientCallResponse, error) {
+	var result T
+	resp, err := call.DoWithUnmarshal(ctx, &result)
+	if err != nil {
+		var zero T
+		return zero, resp, err
+	}
+	return result, resp, nil
+}
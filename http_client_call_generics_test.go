@@ -0,0 +1,87 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type genericsUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type HTTPClientCallGenericsSuite struct {
+	suite.Suite
+	doer *RecordingHTTPClient
+	call *HTTPClientCall
+}
+
+func (suite *HTTPClientCallGenericsSuite) SetupTest() {
+	suite.doer = &RecordingHTTPClient{}
+	suite.call = NewHTTPClientCall("http://example.com", suite.doer).Path("/users")
+}
+
+func (suite *HTTPClientCallGenericsSuite) TestMethodsWithBody() {
+	helpers := map[string]func(context.Context, *HTTPClientCall, any) (genericsUser, *HTTPClientCallResponse, error){
+		http.MethodPost:  Post[genericsUser],
+		http.MethodPut:   Put[genericsUser],
+		http.MethodPatch: Patch[genericsUser],
+	}
+	for method, helper := range helpers {
+		suite.Run(method, func() {
+			user, resp, err := helper(context.Background(), suite.call, genericsUser{ID: 1, Name: "test"})
+			require.NoError(suite.T(), err)
+			suite.Equal(http.StatusOK, resp.StatusCode)
+			suite.Equal(genericsUser{ID: 1, Name: "test"}, user)
+
+			req := suite.doer.Requests[len(suite.doer.Requests)-1]
+			suite.Equal(method, req.Method)
+			suite.Equal("/users", req.URL.Path)
+		})
+	}
+}
+
+func (suite *HTTPClientCallGenericsSuite) TestMethodsWithoutBody() {
+	suite.Run("Get", func() {
+		doer := &MockHTTPClient{Response: &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{HeaderContentType: []string{MIMEApplicationJSON}},
+			Body:       io.NopCloser(bytes.NewBufferString(`[{"id":1,"name":"test"}]`)),
+		}}
+		users, resp, err := Get[[]genericsUser](context.Background(), NewHTTPClientCall("http://example.com", doer))
+		require.NoError(suite.T(), err)
+		suite.Equal(http.StatusOK, resp.StatusCode)
+		suite.Equal([]genericsUser{{ID: 1, Name: "test"}}, users)
+	})
+
+	suite.Run("Delete", func() {
+		doer := &MockHTTPClient{Response: &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{HeaderContentType: []string{MIMETextPlain}},
+			Body:       io.NopCloser(bytes.NewBufferString("deleted")),
+		}}
+		result, resp, err := Delete[string](context.Background(), NewHTTPClientCall("http://example.com", doer))
+		require.NoError(suite.T(), err)
+		suite.Equal(http.StatusOK, resp.StatusCode)
+		suite.Equal("deleted", result)
+	})
+
+	suite.Run("returns the zero value on error", func() {
+		doer := &MockHTTPClient{Err: errors.New("connection refused")}
+		user, resp, err := Get[genericsUser](context.Background(), NewHTTPClientCall("http://example.com", doer))
+		suite.EqualError(err, "connection refused")
+		suite.Nil(resp)
+		suite.Zero(user)
+	})
+}
+
+func TestHTTPClientCallGenericsSuite(t *testing.T) {
+	suite.Run(t, new(HTTPClientCallGenericsSuite))
+}
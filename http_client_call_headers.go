@@ -8,6 +8,7 @@ const (
 	HeaderAcceptEncoding      = "Accept-Encoding"
 	HeaderAllow               = "Allow"
 	HeaderAuthorization       = "Authorization"
+	HeaderCacheControl        = "Cache-Control"
 	HeaderContentDisposition  = "Content-Disposition"
 	HeaderContentEncoding     = "Content-Encoding"
 	HeaderContentLength       = "Content-Length"
@@ -15,6 +16,7 @@ const (
 	HeaderCookie              = "Cookie"
 	HeaderSetCookie           = "Set-Cookie"
 	HeaderIfModifiedSince     = "If-Modified-Since"
+	HeaderLastEventID         = "Last-Event-ID"
 	HeaderLastModified        = "Last-Modified"
 	HeaderLocation            = "Location"
 	HeaderUpgrade             = "Upgrade"
@@ -31,6 +33,17 @@ const (
 	HeaderXRequestedWith      = "X-Requested-With"
 	HeaderServer              = "Server"
 	HeaderOrigin              = "Origin"
+	HeaderRetryAfter          = "Retry-After"
+	HeaderAcceptRanges        = "Accept-Ranges"
+	HeaderContentMD5          = "Content-MD5"
+	HeaderContentRange        = "Content-Range"
+	HeaderDigest              = "Digest"
+	HeaderETag                = "ETag"
+	HeaderIfRange             = "If-Range"
+	HeaderRange               = "Range"
+	HeaderXRateLimitLimit     = "X-RateLimit-Limit"
+	HeaderXRateLimitRemaining = "X-RateLimit-Remaining"
+	HeaderXRateLimitReset     = "X-RateLimit-Reset"
 
 	// Access control
 	HeaderAccessControlRequestMethod    = "Access-Control-Request-Method"
@@ -53,11 +66,27 @@ const (
 	HeaderReferrerPolicy                  = "Referrer-Policy"
 )
 
-// setHeaders sets the headers for the HTTP request.
+// setHeaders sets the headers for the HTTP request. Default headers are only applied when the request does not set them.
 func (r *HTTPClientCall) setHeaders(req *http.Request) {
+	for key, values := range r.defaultHeaders {
+		if _, ok := r.headers[key]; ok {
+			continue
+		}
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
 	for key, values := range r.headers {
 		for _, value := range values {
 			req.Header.Add(key, value)
 		}
 	}
 }
+
+// headerValue returns the first value of the header set on the request, falling back to the default headers.
+func (r *HTTPClientCall) headerValue(key string) string {
+	if values := r.headers.Values(key); len(values) > 0 {
+		return values[0]
+	}
+	return r.defaultHeaders.Get(key)
+}
@@ -0,0 +1,61 @@
+package client
+
+import "net/http"
+
+// HTTPClientDoerFunc is an adapter to allow the use of ordinary functions as HTTPClientDoer.
+type HTTPClientDoerFunc func(*http.Request) (*http.Response, error)
+
+// Do calls f(req).
+func (f HTTPClientDoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware wraps an HTTPClientDoer to add behavior such as logging, authentication or metrics.
+// Middlewares receive the fully built request, after headers and body have been set, once per attempt.
+type Middleware func(next HTTPClientDoer) HTTPClientDoer
+
+// Use appends middlewares to the HTTP request. They run after the client middlewares, in registration order.
+func (r *HTTPClientCall) Use(middlewares ...Middleware) *HTTPClientCall {
+	r.middlewares = append(r.middlewares, middlewares...)
+	return r
+}
+
+// SkipMiddlewares sets whether the middlewares must be bypassed for the HTTP request.
+func (r *HTTPClientCall) SkipMiddlewares(skipMiddlewares bool) *HTTPClientCall {
+	r.skipMiddlewares = skipMiddlewares
+	return r
+}
+
+// WithMiddlewares appends middlewares to every request sent by the client, in registration order.
+func WithMiddlewares(middlewares ...Middleware) ClientOption {
+	return func(c *HTTPClient) {
+		c.middlewares = append(c.middlewares, middlewares...)
+	}
+}
+
+// doer returns the HTTP client wrapped by the middlewares, the first registered middleware being the outermost
+// one, then by the bulkhead, the rate limiter and the circuit breaker.
+func (r *HTTPClientCall) doer() HTTPClientDoer {
+	doer := r.client
+	if !r.skipMiddlewares {
+		doer = chainMiddlewares(doer, r.middlewares)
+	}
+	if r.bulkhead != nil {
+		doer = r.bulkhead.wrap(doer)
+	}
+	if r.rateLimiter != nil {
+		doer = r.rateLimiter.wrap(doer)
+	}
+	if r.circuitBreaker != nil {
+		doer = r.circuitBreaker.wrap(doer)
+	}
+	return doer
+}
+
+// chainMiddlewares wraps doer with the middlewares so that middlewares[0] runs first.
+func chainMiddlewares(doer HTTPClientDoer, middlewares []Middleware) HTTPClientDoer {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		doer = middlewares[i](doer)
+	}
+	return doer
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type HTTPClientCallMiddlewareSuite struct {
+	suite.Suite
+	doer *RecordingHTTPClient
+}
+
+func (suite *HTTPClientCallMiddlewareSuite) SetupTest() {
+	suite.doer = &RecordingHTTPClient{}
+}
+
+func recordMiddleware(name string, order *[]string) Middleware {
+	return func(next HTTPClientDoer) HTTPClientDoer {
+		return HTTPClientDoerFunc(func(req *http.Request) (*http.Response, error) {
+			*order = append(*order, name)
+			return next.Do(req)
+		})
+	}
+}
+
+func (suite *HTTPClientCallMiddlewareSuite) TestMiddlewareOrder() {
+	suite.Run("runs client middlewares before request middlewares in registration order", func() {
+		var order []string
+		c := NewHTTPClient("http://example.com", suite.doer, WithMiddlewares(
+			recordMiddleware("client-1", &order),
+			recordMiddleware("client-2", &order),
+		))
+
+		_, err := c.NewCall().
+			Method(http.MethodGet).
+			Use(recordMiddleware("request", &order)).
+			Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Equal([]string{"client-1", "client-2", "request"}, order)
+	})
+
+	suite.Run("request middlewares do not leak into the client", func() {
+		var order []string
+		c := NewHTTPClient("http://example.com", suite.doer, WithMiddlewares(recordMiddleware("client", &order)))
+
+		c.NewCall().Use(recordMiddleware("first", &order))
+		_, err := c.NewCall().Method(http.MethodGet).Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Equal([]string{"client"}, order)
+	})
+}
+
+func (suite *HTTPClientCallMiddlewareSuite) TestSkipMiddlewares() {
+	suite.Run("bypasses the middlewares for a single request", func() {
+		var order []string
+		c := NewHTTPClient("http://example.com", suite.doer, WithMiddlewares(recordMiddleware("client", &order)))
+
+		_, err := c.NewCall().Method(http.MethodGet).SkipMiddlewares(true).Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Empty(order)
+		suite.Len(suite.doer.Requests, 1)
+	})
+}
+
+func (suite *HTTPClientCallMiddlewareSuite) TestMiddlewareSeesBuiltRequest() {
+	suite.Run("receives the request with headers and body", func() {
+		var header string
+		var contentLength int64
+		inspect := func(next HTTPClientDoer) HTTPClientDoer {
+			return HTTPClientDoerFunc(func(req *http.Request) (*http.Response, error) {
+				header = req.Header.Get(HeaderXRequestID)
+				contentLength = req.ContentLength
+				req.Header.Set(HeaderAuthorization, "Bearer token")
+				return next.Do(req)
+			})
+		}
+
+		_, err := NewHTTPClientCall("http://example.com", suite.doer).
+			Method(http.MethodPost).
+			Headers(http.Header{HeaderXRequestID: []string{"id"}}).
+			Body(map[string]string{"key": "value"}).
+			Use(inspect).
+			Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Equal("id", header)
+		suite.Positive(contentLength)
+		suite.Equal("Bearer token", suite.doer.Requests[0].Header.Get(HeaderAuthorization))
+	})
+}
+
+func TestHTTPClientCallMiddlewareSuite(t *testing.T) {
+	suite.Run(t, new(HTTPClientCallMiddlewareSuite))
+}
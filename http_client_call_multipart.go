@@ -0,0 +1,177 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// quoteEscaper escapes the quotes and backslashes of Content-Disposition parameters.
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// multipartPart describes a part of a multipart form and how to open its content.
+type multipartPart struct {
+	header     textproto.MIMEHeader
+	open       func() (io.ReadCloser, error)
+	replayable bool
+}
+
+// MultipartForm builds a multipart/form-data request body. Set it with Body: its parts are streamed through an
+// io.Pipe, so files are never fully buffered in memory.
+type MultipartForm struct {
+	boundary string
+	parts    []multipartPart
+}
+
+// NewMultipartForm creates an empty MultipartForm with a random boundary.
+func NewMultipartForm() *MultipartForm {
+	return &MultipartForm{
+		boundary: multipart.NewWriter(io.Discard).Boundary(),
+		parts:    nil,
+	}
+}
+
+// Field adds a form field.
+func (m *MultipartForm) Field(name, value string) *MultipartForm {
+	header := make(textproto.MIMEHeader)
+	header.Set(HeaderContentDisposition, fmt.Sprintf(`form-data; name="%s"`, quoteEscaper.Replace(name)))
+	return m.addPart(header, strings.NewReader(value))
+}
+
+// File adds a file read from content with the application/octet-stream content type.
+// The file can only be sent again on retries when content implements io.Seeker.
+func (m *MultipartForm) File(fieldName, fileName string, content io.Reader) *MultipartForm {
+	return m.FileWithContentType(fieldName, fileName, MIMEOctetStream, content)
+}
+
+// FileWithContentType adds a file read from content with the given content type.
+// The file can only be sent again on retries when content implements io.Seeker.
+func (m *MultipartForm) FileWithContentType(fieldName, fileName, contentType string, content io.Reader) *MultipartForm {
+	return m.addPart(fileHeader(fieldName, fileName, contentType), content)
+}
+
+// FileFromPath adds the file at path. The file is opened when the body is sent and its content type is guessed from
+// the file extension.
+func (m *MultipartForm) FileFromPath(fieldName, path string) *MultipartForm {
+	contentType := mime.TypeByExtension(filepath.Ext(path))
+	if contentType == "" {
+		contentType = MIMEOctetStream
+	}
+	m.parts = append(m.parts, multipartPart{
+		header: fileHeader(fieldName, filepath.Base(path), contentType),
+		open: func() (io.ReadCloser, error) {
+			return os.Open(path)
+		},
+		replayable: true,
+	})
+	return m
+}
+
+// Part adds a part with custom headers, such as Content-Disposition, Content-Type or Content-ID.
+// The part can only be sent again on retries when content implements io.Seeker.
+func (m *MultipartForm) Part(header textproto.MIMEHeader, content io.Reader) *MultipartForm {
+	return m.addPart(header, content)
+}
+
+// ContentType returns the multipart/form-data content type including the boundary.
+func (m *MultipartForm) ContentType() string {
+	return mime.FormatMediaType(MIMEMultipartForm, map[string]string{"boundary": m.boundary})
+}
+
+// Reader returns a reader streaming the encoded form. Parts are written by a goroutine as the reader is consumed.
+func (m *MultipartForm) Reader() io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		_ = pw.CloseWithError(m.writeTo(pw))
+	}()
+	return pr
+}
+
+// isReplayable reports whether every part can be opened again to retry the request.
+func (m *MultipartForm) isReplayable() bool {
+	for _, part := range m.parts {
+		if !part.replayable {
+			return false
+		}
+	}
+	return true
+}
+
+// addPart adds a part whose content is read from content, rewinding it when it implements io.Seeker.
+func (m *MultipartForm) addPart(header textproto.MIMEHeader, content io.Reader) *MultipartForm {
+	seeker, replayable := content.(io.Seeker)
+	m.parts = append(m.parts, multipartPart{
+		header: header,
+		open: func() (io.ReadCloser, error) {
+			if replayable {
+				if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+					return nil, err
+				}
+			}
+			return io.NopCloser(content), nil
+		},
+		replayable: replayable,
+	})
+	return m
+}
+
+// writeTo encodes the form into w.
+func (m *MultipartForm) writeTo(w io.Writer) error {
+	writer := multipart.NewWriter(w)
+	if err := writer.SetBoundary(m.boundary); err != nil {
+		return err
+	}
+	for _, part := range m.parts {
+		if err := writePart(writer, part); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// writePart copies the content of a part into the multipart writer.
+func writePart(writer *multipart.Writer, part multipartPart) error {
+	content, err := part.open()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = content.Close()
+	}()
+
+	dst, err := writer.CreatePart(part.header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, content)
+	return err
+}
+
+// fileHeader builds the headers of a file part.
+func fileHeader(fieldName, fileName, contentType string) textproto.MIMEHeader {
+	header := make(textproto.MIMEHeader)
+	header.Set(HeaderContentDisposition, fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		quoteEscaper.Replace(fieldName), quoteEscaper.Replace(fileName)))
+	header.Set(HeaderContentType, contentType)
+	return header
+}
+
+// setMultipartBody streams the multipart form as the request body.
+func (r *HTTPClientCall) setMultipartBody(req *http.Request, form *MultipartForm) {
+	if r.headerValue(HeaderContentType) == "" {
+		req.Header.Set(HeaderContentType, form.ContentType())
+	}
+	req.Body = form.Reader()
+	req.ContentLength = -1
+	if form.isReplayable() {
+		req.GetBody = func() (io.ReadCloser, error) {
+			return form.Reader(), nil
+		}
+	}
+}
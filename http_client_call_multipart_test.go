@@ -0,0 +1,167 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type multipartReadPart struct {
+	header   textproto.MIMEHeader
+	name     string
+	fileName string
+	content  string
+}
+
+type HTTPClientCallMultipartSuite struct {
+	suite.Suite
+}
+
+func (suite *HTTPClientCallMultipartSuite) readForm(contentType string, body io.Reader) []multipartReadPart {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	require.NoError(suite.T(), err)
+	suite.Equal(MIMEMultipartForm, mediaType)
+
+	var parts []multipartReadPart
+	reader := multipart.NewReader(body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return parts
+		}
+		require.NoError(suite.T(), err)
+		content, err := io.ReadAll(part)
+		require.NoError(suite.T(), err)
+		parts = append(parts, multipartReadPart{
+			header:   part.Header,
+			name:     part.FormName(),
+			fileName: part.FileName(),
+			content:  string(content),
+		})
+	}
+}
+
+func (suite *HTTPClientCallMultipartSuite) TestMultipartForm() {
+	suite.Run("streams fields, files and custom parts", func() {
+		path := filepath.Join(suite.T().TempDir(), "report.json")
+		require.NoError(suite.T(), os.WriteFile(path, []byte(`{"key":"value"}`), 0o600))
+
+		header := make(textproto.MIMEHeader)
+		header.Set(HeaderContentDisposition, `form-data; name="metadata"`)
+		header.Set(HeaderContentType, MIMEApplicationJSON)
+		header.Set("Content-ID", "<metadata>")
+
+		form := NewMultipartForm().
+			Field("title", `quarterly "report"`).
+			File("attachment", "notes.txt", strings.NewReader("notes")).
+			FileWithContentType("image", "pixel.png", "image/png", bytes.NewReader([]byte{0x89})).
+			FileFromPath("report", path).
+			Part(header, strings.NewReader(`{"id":1}`))
+
+		body := form.Reader()
+		parts := suite.readForm(form.ContentType(), body)
+		require.Len(suite.T(), parts, 5)
+
+		suite.Equal("title", parts[0].name)
+		suite.Equal(`quarterly "report"`, parts[0].content)
+
+		suite.Equal("attachment", parts[1].name)
+		suite.Equal("notes.txt", parts[1].fileName)
+		suite.Equal(MIMEOctetStream, parts[1].header.Get(HeaderContentType))
+		suite.Equal("notes", parts[1].content)
+
+		suite.Equal("image/png", parts[2].header.Get(HeaderContentType))
+		suite.Equal("\x89", parts[2].content)
+
+		suite.Equal("report", parts[3].name)
+		suite.Equal("report.json", parts[3].fileName)
+		suite.Equal(MIMEApplicationJSON, parts[3].header.Get(HeaderContentType))
+		suite.Equal(`{"key":"value"}`, parts[3].content)
+
+		suite.Equal("metadata", parts[4].name)
+		suite.Equal("<metadata>", parts[4].header.Get("Content-ID"))
+		suite.Equal(`{"id":1}`, parts[4].content)
+	})
+
+	suite.Run("reports errors through the reader", func() {
+		form := NewMultipartForm().FileFromPath("file", filepath.Join(suite.T().TempDir(), "missing.txt"))
+		_, err := io.ReadAll(form.Reader())
+		suite.ErrorIs(err, os.ErrNotExist)
+	})
+
+	suite.Run("is replayable only when every part can be reopened", func() {
+		suite.True(NewMultipartForm().Field("key", "value").File("file", "a.txt", strings.NewReader("a")).isReplayable())
+		suite.False(NewMultipartForm().File("file", "a.txt", io.MultiReader(strings.NewReader("a"))).isReplayable())
+	})
+}
+
+func (suite *HTTPClientCallMultipartSuite) TestDo_Multipart() {
+	suite.Run("sends the form with its content type and retries it", func() {
+		doer := &SequenceHTTPClient{Responses: []*http.Response{
+			newStatusResponse(http.StatusServiceUnavailable),
+			newStatusResponse(http.StatusOK),
+		}}
+		policy := NewRetryPolicy()
+		policy.InitialBackoff = time.Millisecond
+		form := NewMultipartForm().Field("key", "value").File("file", "a.txt", strings.NewReader("content"))
+
+		resp, err := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodPost).
+			Body(form).
+			Retry(policy).
+			Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Equal(http.StatusOK, resp.StatusCode)
+		require.Len(suite.T(), doer.Bodies, 2)
+		for _, body := range doer.Bodies {
+			parts := suite.readForm(form.ContentType(), strings.NewReader(body))
+			require.Len(suite.T(), parts, 2)
+			suite.Equal("value", parts[0].content)
+			suite.Equal("content", parts[1].content)
+		}
+	})
+
+	suite.Run("does not retry a form with a non seekable reader", func() {
+		doer := &SequenceHTTPClient{Responses: []*http.Response{
+			newStatusResponse(http.StatusServiceUnavailable),
+			newStatusResponse(http.StatusOK),
+		}}
+		form := NewMultipartForm().File("file", "a.txt", io.MultiReader(strings.NewReader("content")))
+
+		resp, err := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodPost).
+			Body(form).
+			Retry(NewRetryPolicy()).
+			Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Equal(http.StatusServiceUnavailable, resp.StatusCode)
+		suite.Equal(1, doer.Calls)
+	})
+
+	suite.Run("sets the content type and unknown length on the request", func() {
+		doer := &RecordingHTTPClient{}
+		form := NewMultipartForm().Field("key", "value")
+
+		_, err := NewHTTPClientCall("http://example.com", doer).Method(http.MethodPost).Body(form).Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Equal(form.ContentType(), doer.Requests[0].Header.Get(HeaderContentType))
+		suite.Equal(int64(-1), doer.Requests[0].ContentLength)
+	})
+}
+
+func TestHTTPClientCallMultipartSuite(t *testing.T) {
+	suite.Run(t, new(HTTPClientCallMultipartSuite))
+}
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// problemDetailsMembers lists the members defined by RFC 9457, which are not stored as extensions.
+var problemDetailsMembers = []string{"type", "title", "status", "detail", "instance"}
+
+// ProblemDetails represents an RFC 9457 (formerly RFC 7807) problem details object.
+// Error responses with the application/problem+json content type are decoded into it and exposed through HTTPError.
+type ProblemDetails struct {
+	// Extensions holds the extension members of the problem.
+	Extensions map[string]any `json:"-"`
+	// Type is a URI reference that identifies the problem type. An empty value means "about:blank".
+	Type string `json:"type,omitempty"`
+	// Title is a short, human-readable summary of the problem type.
+	Title string `json:"title,omitempty"`
+	// Detail is a human-readable explanation specific to this occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+	// Instance is a URI reference that identifies the specific occurrence of the problem.
+	Instance string `json:"instance,omitempty"`
+	// Status is the HTTP status code generated by the origin server.
+	Status int `json:"status,omitempty"`
+}
+
+// problemDetailsAlias prevents the recursion of the ProblemDetails JSON methods.
+type problemDetailsAlias ProblemDetails
+
+// Error returns the title and detail of the problem.
+func (p *ProblemDetails) Error() string {
+	switch {
+	case p.Title != "" && p.Detail != "":
+		return fmt.Sprintf("%s: %s", p.Title, p.Detail)
+	case p.Title != "":
+		return p.Title
+	case p.Detail != "":
+		return p.Detail
+	default:
+		return fmt.Sprintf("problem details with status %d", p.Status)
+	}
+}
+
+// UnmarshalJSON decodes the standard members and collects every other member into Extensions.
+func (p *ProblemDetails) UnmarshalJSON(data []byte) error {
+	var members problemDetailsAlias
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+	var extensions map[string]any
+	if err := json.Unmarshal(data, &extensions); err != nil {
+		return err
+	}
+	for _, name := range problemDetailsMembers {
+		delete(extensions, name)
+	}
+	if len(extensions) == 0 {
+		extensions = nil
+	}
+
+	*p = ProblemDetails(members)
+	p.Extensions = extensions
+	return nil
+}
+
+// MarshalJSON encodes the standard members together with the extension members.
+func (p ProblemDetails) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(problemDetailsAlias(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return data, err
+	}
+
+	members := make(map[string]any, len(p.Extensions)+len(problemDetailsMembers))
+	for name, value := range p.Extensions {
+		members[name] = value
+	}
+	if err = json.Unmarshal(data, &members); err != nil {
+		return nil, err
+	}
+	return json.Marshal(members)
+}
+
+// isProblemJSON reports whether the content type is application/problem+json.
+func isProblemJSON(contentType string) bool {
+	mediaType, ok := parseMediaType(contentType)
+	return ok && mediaType == MIMEApplicationProblemJSON
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type HTTPClientCallProblemSuite struct {
+	suite.Suite
+}
+
+func (suite *HTTPClientCallProblemSuite) TestProblemDetails_JSON() {
+	body := `{"type":"https://example.com/probs/out-of-credit","title":"You do not have enough credit.",` +
+		`"status":403,"detail":"Your current balance is 30, but that costs 50.","instance":"/account/12345/msgs/abc",` +
+		`"balance":30,"accounts":["/account/12345","/account/67890"]}`
+
+	suite.Run("decodes standard and extension members", func() {
+		var problem ProblemDetails
+		require.NoError(suite.T(), json.Unmarshal([]byte(body), &problem))
+		suite.Equal("https://example.com/probs/out-of-credit", problem.Type)
+		suite.Equal("You do not have enough credit.", problem.Title)
+		suite.Equal(http.StatusForbidden, problem.Status)
+		suite.Equal("Your current balance is 30, but that costs 50.", problem.Detail)
+		suite.Equal("/account/12345/msgs/abc", problem.Instance)
+		suite.Equal(map[string]any{
+			"balance":  float64(30),
+			"accounts": []any{"/account/12345", "/account/67890"},
+		}, problem.Extensions)
+	})
+
+	suite.Run("encodes standard and extension members", func() {
+		var problem ProblemDetails
+		require.NoError(suite.T(), json.Unmarshal([]byte(body), &problem))
+		data, err := json.Marshal(problem)
+		require.NoError(suite.T(), err)
+		suite.JSONEq(body, string(data))
+	})
+
+	suite.Run("leaves extensions empty when there are none", func() {
+		var problem ProblemDetails
+		require.NoError(suite.T(), json.Unmarshal([]byte(`{"title":"Not Found","status":404}`), &problem))
+		suite.Nil(problem.Extensions)
+	})
+}
+
+func (suite *HTTPClientCallProblemSuite) TestProblemDetails_Error() {
+	suite.Equal("Forbidden: no credit", (&ProblemDetails{Title: "Forbidden", Detail: "no credit"}).Error())
+	suite.Equal("Forbidden", (&ProblemDetails{Title: "Forbidden"}).Error())
+	suite.Equal("no credit", (&ProblemDetails{Detail: "no credit"}).Error())
+	suite.Equal("problem details with status 403", (&ProblemDetails{Status: http.StatusForbidden}).Error())
+}
+
+func (suite *HTTPClientCallProblemSuite) TestDoWithUnmarshal_Problem() {
+	suite.Run("exposes problem+json error responses as ProblemDetails", func() {
+		doer := &MockHTTPClient{Response: &http.Response{
+			StatusCode: http.StatusForbidden,
+			Header:     http.Header{HeaderContentType: []string{MIMEApplicationProblemJSON + "; charset=utf-8"}},
+			Body:       io.NopCloser(bytes.NewBufferString(`{"title":"Forbidden","status":403,"trace_id":"abc"}`)),
+		}}
+
+		var success map[string]string
+		_, err := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodGet).
+			DoWithUnmarshal(context.Background(), &success)
+
+		var problem *ProblemDetails
+		require.True(suite.T(), errors.As(err, &problem))
+		suite.Equal("Forbidden", problem.Title)
+		suite.Equal(http.StatusForbidden, problem.Status)
+		suite.Equal("abc", problem.Extensions["trace_id"])
+
+		var httpError *HTTPError
+		require.True(suite.T(), errors.As(err, &httpError))
+		suite.Same(problem, httpError.Problem)
+	})
+
+	suite.Run("does not unwrap other error responses", func() {
+		doer := &MockHTTPClient{Response: &http.Response{
+			StatusCode: http.StatusForbidden,
+			Header:     http.Header{HeaderContentType: []string{MIMEApplicationJSON}},
+			Body:       io.NopCloser(bytes.NewBufferString(`{"title":"Forbidden"}`)),
+		}}
+
+		var success map[string]string
+		_, err := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodGet).
+			DoWithUnmarshal(context.Background(), &success)
+
+		var problem *ProblemDetails
+		suite.False(errors.As(err, &problem))
+	})
+}
+
+func (suite *HTTPClientCallProblemSuite) TestIsProblemJSON() {
+	suite.True(isProblemJSON(MIMEApplicationProblemJSON))
+	suite.True(isProblemJSON(MIMEApplicationProblemJSON + "; charset"))
+	suite.False(isProblemJSON(MIMEApplicationJSON))
+	suite.False(isProblemJSON("invalid content type;"))
+}
+
+func TestHTTPClientCallProblemSuite(t *testing.T) {
+	suite.Run(t, new(HTTPClientCallProblemSuite))
+}
@@ -0,0 +1,185 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// unixTimestampThreshold separates X-RateLimit-Reset values given in seconds from now from Unix timestamps.
+const unixTimestampThreshold = 1_000_000_000
+
+// RateLimiter limits the rate of requests with a token bucket per key. Requests wait in Do until a token is
+// available or their context is done. It is safe for concurrent use; its settings must not be modified once it
+// is in use.
+type RateLimiter struct {
+	// Key returns the key of the bucket used for a request, HostKey when nil.
+	Key KeyFunc
+	// Rate is the number of tokens added to each bucket per second. Zero or less disables the limit.
+	Rate float64
+	// Burst is the capacity of each bucket, at least 1.
+	Burst int
+	// AdaptToHeaders lowers the available tokens to the X-RateLimit-Remaining response header and, when no
+	// request remains, waits until the time given by the X-RateLimit-Reset header.
+	AdaptToHeaders bool
+	buckets        map[string]*tokenBucket
+	mu             sync.Mutex
+}
+
+// tokenBucket holds the tokens of a key. Tokens go negative when requests reserve tokens they wait for.
+type tokenBucket struct {
+	updated      time.Time
+	blockedUntil time.Time
+	tokens       float64
+}
+
+// NewRateLimiter creates a RateLimiter keyed by host that allows rate requests per second with the given burst.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		Key:            HostKey,
+		Rate:           rate,
+		Burst:          burst,
+		AdaptToHeaders: false,
+		buckets:        make(map[string]*tokenBucket),
+		mu:             sync.Mutex{},
+	}
+}
+
+// RateLimiter sets the rate limiter of the HTTP request. It is checked before every attempt, inside the circuit
+// breaker and outside of the middlewares. A nil limiter disables it.
+func (r *HTTPClientCall) RateLimiter(limiter *RateLimiter) *HTTPClientCall {
+	r.rateLimiter = limiter
+	return r
+}
+
+// WithRateLimiter sets the rate limiter shared by every request sent by the client.
+func WithRateLimiter(limiter *RateLimiter) ClientOption {
+	return func(c *HTTPClient) {
+		c.rateLimiter = limiter
+	}
+}
+
+// Wait blocks until a token of the key bucket is available or the context is done. It fails immediately when
+// the token would only be available after the context deadline.
+func (l *RateLimiter) Wait(ctx context.Context, key string) error {
+	if l.Rate <= 0 {
+		return nil
+	}
+	delay := l.reserve(key, time.Now())
+	if delay <= 0 {
+		return nil
+	}
+	if exceedsDeadline(ctx, delay) {
+		l.cancel(key)
+		return fmt.Errorf("rate limiter wait of %s exceeds the context deadline: %w", delay, context.DeadlineExceeded)
+	}
+	if err := sleepContext(ctx, delay); err != nil {
+		l.cancel(key)
+		return err
+	}
+	return nil
+}
+
+// wrap returns a doer that waits for a token before sending each request.
+func (l *RateLimiter) wrap(next HTTPClientDoer) HTTPClientDoer {
+	return HTTPClientDoerFunc(func(req *http.Request) (*http.Response, error) {
+		key := l.key(req)
+		if err := l.Wait(req.Context(), key); err != nil {
+			closeRequestBody(req)
+			return nil, err
+		}
+		resp, err := next.Do(req)
+		if err == nil && l.AdaptToHeaders {
+			l.adapt(key, resp.Header, time.Now())
+		}
+		return resp, err
+	})
+}
+
+// reserve takes a token from the key bucket and returns how long to wait until it is available.
+func (l *RateLimiter) reserve(key string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	bucket := l.bucket(key, now)
+	bucket.tokens--
+	var delay time.Duration
+	if bucket.tokens < 0 {
+		delay = time.Duration(-bucket.tokens / l.Rate * float64(time.Second))
+	}
+	if blocked := bucket.blockedUntil.Sub(now); blocked > delay {
+		delay = blocked
+	}
+	return delay
+}
+
+// cancel gives back a token reserved by a request that stopped waiting.
+func (l *RateLimiter) cancel(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	bucket := l.bucket(key, time.Now())
+	bucket.tokens = min(bucket.tokens+1, float64(l.burst()))
+}
+
+// adapt lowers the tokens of the key bucket to the requests remaining according to the response headers.
+func (l *RateLimiter) adapt(key string, header http.Header, now time.Time) {
+	remaining, err := strconv.Atoi(header.Get(HeaderXRateLimitRemaining))
+	if err != nil || remaining < 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	bucket := l.bucket(key, now)
+	bucket.tokens = min(bucket.tokens, float64(remaining))
+	if reset, ok := parseRateLimitReset(header.Get(HeaderXRateLimitReset), now); ok && remaining == 0 {
+		bucket.blockedUntil = reset
+	}
+}
+
+// bucket returns the bucket of key refilled up to now. It must be called with the lock held.
+func (l *RateLimiter) bucket(key string, now time.Time) *tokenBucket {
+	if l.buckets == nil {
+		l.buckets = make(map[string]*tokenBucket)
+	}
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{
+			updated:      now,
+			blockedUntil: time.Time{},
+			tokens:       float64(l.burst()),
+		}
+		l.buckets[key] = bucket
+	}
+	if elapsed := now.Sub(bucket.updated); elapsed > 0 {
+		bucket.tokens = min(bucket.tokens+elapsed.Seconds()*l.Rate, float64(l.burst()))
+		bucket.updated = now
+	}
+	return bucket
+}
+
+// key returns the key of the bucket used for the request.
+func (l *RateLimiter) key(req *http.Request) string {
+	if l.Key == nil {
+		return HostKey(req)
+	}
+	return l.Key(req)
+}
+
+// burst returns the capacity of the buckets.
+func (l *RateLimiter) burst() int {
+	return max(l.Burst, 1)
+}
+
+// parseRateLimitReset parses an X-RateLimit-Reset header given either in seconds from now or as a Unix timestamp.
+func parseRateLimitReset(value string, now time.Time) (time.Time, bool) {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return time.Time{}, false
+	}
+	if seconds >= unixTimestampThreshold {
+		return time.Unix(seconds, 0), true
+	}
+	return now.Add(time.Duration(seconds) * time.Second), true
+}
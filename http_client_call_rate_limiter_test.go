@@ -0,0 +1,167 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type HTTPClientCallRateLimiterSuite struct {
+	suite.Suite
+}
+
+func (suite *HTTPClientCallRateLimiterSuite) TestWait() {
+	now := time.Now()
+
+	suite.Run("allows the burst immediately and then waits for tokens", func() {
+		limiter := NewRateLimiter(20, 2)
+		suite.Zero(limiter.reserve("example.com", now))
+		suite.Zero(limiter.reserve("example.com", now))
+		suite.Equal(50*time.Millisecond, limiter.reserve("example.com", now))
+		suite.Equal(100*time.Millisecond, limiter.reserve("example.com", now))
+	})
+
+	suite.Run("refills the tokens over time", func() {
+		limiter := NewRateLimiter(20, 1)
+		suite.Zero(limiter.reserve("example.com", now))
+		suite.Zero(limiter.reserve("example.com", now.Add(50*time.Millisecond)))
+	})
+
+	suite.Run("keeps a bucket per key", func() {
+		limiter := NewRateLimiter(1, 1)
+		suite.Zero(limiter.reserve("a.example.com", now))
+		suite.Zero(limiter.reserve("b.example.com", now))
+		suite.Equal(time.Second, limiter.reserve("a.example.com", now))
+	})
+
+	suite.Run("does not limit without a rate", func() {
+		limiter := NewRateLimiter(0, 0)
+		for range 100 {
+			suite.NoError(limiter.Wait(context.Background(), "example.com"))
+		}
+	})
+
+	suite.Run("stops waiting when the context is cancelled and gives the token back", func() {
+		limiter := NewRateLimiter(0.001, 1)
+		suite.NoError(limiter.Wait(context.Background(), "example.com"))
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		suite.ErrorIs(limiter.Wait(ctx, "example.com"), context.Canceled)
+		suite.LessOrEqual(limiter.reserve("example.com", time.Now()), 1000*time.Second)
+	})
+
+	suite.Run("fails fast when the wait exceeds the context deadline", func() {
+		limiter := NewRateLimiter(0.001, 1)
+		suite.NoError(limiter.Wait(context.Background(), "example.com"))
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		err := limiter.Wait(ctx, "example.com")
+		suite.ErrorIs(err, context.DeadlineExceeded)
+		suite.ErrorContains(err, "exceeds the context deadline")
+		suite.NoError(ctx.Err())
+		suite.LessOrEqual(limiter.reserve("example.com", time.Now()), 1000*time.Second)
+	})
+}
+
+func (suite *HTTPClientCallRateLimiterSuite) rateLimitHeader(remaining, reset string) http.Header {
+	header := http.Header{}
+	header.Set(HeaderXRateLimitRemaining, remaining)
+	if reset != "" {
+		header.Set(HeaderXRateLimitReset, reset)
+	}
+	return header
+}
+
+func (suite *HTTPClientCallRateLimiterSuite) TestAdaptToHeaders() {
+	now := time.Now()
+
+	suite.Run("waits until the reset when no request remains", func() {
+		limiter := NewRateLimiter(100, 10)
+		limiter.adapt("example.com", suite.rateLimitHeader("0", "2"), now)
+		suite.Equal(2*time.Second, limiter.reserve("example.com", now))
+	})
+
+	suite.Run("accepts the reset as a Unix timestamp", func() {
+		limiter := NewRateLimiter(100, 10)
+		reset := now.Add(3 * time.Second).Truncate(time.Second)
+		limiter.adapt("example.com", suite.rateLimitHeader("0", strconv.FormatInt(reset.Unix(), 10)), now)
+		suite.Equal(reset.Sub(now), limiter.reserve("example.com", now))
+	})
+
+	suite.Run("lowers the tokens to the remaining requests", func() {
+		limiter := NewRateLimiter(10, 10)
+		limiter.adapt("example.com", suite.rateLimitHeader("1", ""), now)
+		suite.Zero(limiter.reserve("example.com", now))
+		suite.Equal(100*time.Millisecond, limiter.reserve("example.com", now))
+	})
+
+	suite.Run("ignores missing or invalid headers", func() {
+		limiter := NewRateLimiter(10, 1)
+		limiter.adapt("example.com", suite.rateLimitHeader("many", ""), now)
+		suite.Zero(limiter.reserve("example.com", now))
+	})
+}
+
+func (suite *HTTPClientCallRateLimiterSuite) TestDo() {
+	suite.Run("limits the requests of the client and adapts to the response headers", func() {
+		resp := newStatusResponse(http.StatusOK)
+		resp.Header.Set(HeaderXRateLimitRemaining, "0")
+		resp.Header.Set(HeaderXRateLimitReset, "60")
+		limiter := NewRateLimiter(100, 10)
+		limiter.AdaptToHeaders = true
+		c := NewHTTPClient("http://example.com", &MockHTTPClient{Response: resp}, WithRateLimiter(limiter))
+
+		_, err := c.NewCall().Method(http.MethodGet).Do(context.Background())
+		require.NoError(suite.T(), err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_, err = c.NewCall().Method(http.MethodGet).Do(ctx)
+		suite.ErrorIs(err, context.DeadlineExceeded)
+		suite.ErrorContains(err, "exceeds the context deadline")
+	})
+
+	suite.Run("closes the body of requests that stop waiting", func() {
+		limiter := NewRateLimiter(1, 1)
+		doer := &MockHTTPClient{Response: newStatusResponse(http.StatusOK)}
+		_, err := NewHTTPClientCall("http://example.com", doer).Method(http.MethodGet).RateLimiter(limiter).
+			Do(context.Background())
+		require.NoError(suite.T(), err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		body := &CloseCountingBody{Reader: strings.NewReader("payload")}
+		_, err = NewHTTPClientCall("http://example.com", doer).Method(http.MethodPost).Body(body).
+			RateLimiter(limiter).Do(ctx)
+		suite.ErrorIs(err, context.Canceled)
+		suite.Equal(int32(1), body.Closes.Load())
+	})
+
+	suite.Run("keys the buckets by route", func() {
+		limiter := NewRateLimiter(0.001, 1)
+		limiter.Key = RouteKey
+		doer := &MockHTTPClient{Response: newStatusResponse(http.StatusOK)}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		_, err := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodGet).Route("/users/{id}").RateLimiter(limiter).Do(ctx)
+		suite.NoError(err)
+		_, err = NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodGet).Route("/orders/{id}").RateLimiter(limiter).Do(ctx)
+		suite.NoError(err)
+		_, err = NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodGet).Route("/users/{id}").RateLimiter(limiter).Do(ctx)
+		suite.ErrorIs(err, context.DeadlineExceeded)
+	})
+}
+
+func TestHTTPClientCallRateLimiterSuite(t *testing.T) {
+	suite.Run(t, new(HTTPClientCallRateLimiterSuite))
+}
@@ -0,0 +1,288 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default values used by NewRetryPolicy and by RetryPolicy fields left at their zero value.
+const (
+	defaultRetryMaxAttempts    = 3
+	defaultRetryInitialBackoff = 100 * time.Millisecond
+	defaultRetryMaxBackoff     = 10 * time.Second
+	defaultRetryMultiplier     = 2.0
+	defaultRetryMaxRetryAfter  = time.Minute
+)
+
+// JitterStrategy defines how randomness is applied to the backoff delay between attempts.
+type JitterStrategy int
+
+const (
+	// JitterNone uses the exponential backoff delay as is.
+	JitterNone JitterStrategy = iota
+	// JitterFull waits a random delay between zero and the exponential backoff delay.
+	JitterFull
+	// JitterDecorrelated waits a random delay between the initial backoff and three times the previous delay.
+	JitterDecorrelated
+)
+
+// RetryPolicy configures how many times and how often a request is retried.
+type RetryPolicy struct {
+	// RetryOnStatus reports whether a response with the given status code must be retried.
+	RetryOnStatus func(statusCode int) bool
+	// RetryOnError reports whether a transport error must be retried.
+	RetryOnError func(err error) bool
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts.
+	MaxBackoff time.Duration
+	// Multiplier is the factor applied to the delay after every attempt.
+	Multiplier float64
+	// Jitter is the strategy used to randomize the delay.
+	Jitter JitterStrategy
+	// MaxRetryAfter is the longest Retry-After delay honored. The throttled response is returned without retrying
+	// when the server asks to wait longer.
+	MaxRetryAfter time.Duration
+}
+
+// NewRetryPolicy creates a RetryPolicy with exponential backoff, full jitter and the default retry predicates.
+func NewRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		RetryOnStatus:  DefaultRetryOnStatus,
+		RetryOnError:   DefaultRetryOnError,
+		MaxAttempts:    defaultRetryMaxAttempts,
+		InitialBackoff: defaultRetryInitialBackoff,
+		MaxBackoff:     defaultRetryMaxBackoff,
+		Multiplier:     defaultRetryMultiplier,
+		Jitter:         JitterFull,
+		MaxRetryAfter:  defaultRetryMaxRetryAfter,
+	}
+}
+
+// DefaultRetryOnStatus retries throttled responses and transient gateway errors.
+func DefaultRetryOnStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// DefaultRetryOnError retries every transport error except the cancellation of the request context and
+// the rejection of the request by an open circuit breaker or a full bulkhead.
+func DefaultRetryOnError(err error) bool {
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) &&
+		!errors.Is(err, ErrCircuitOpen) && !errors.Is(err, ErrBulkheadFull) && !errors.Is(err, ErrBulkheadTimeout)
+}
+
+// Retry sets the retry policy for the HTTP request. A nil policy disables retries.
+func (r *HTTPClientCall) Retry(policy *RetryPolicy) *HTTPClientCall {
+	r.retryPolicy = policy
+	return r
+}
+
+// maxAttempts returns the configured number of attempts, falling back to the default.
+func (p *RetryPolicy) maxAttempts() int {
+	if p.MaxAttempts <= 0 {
+		return defaultRetryMaxAttempts
+	}
+	return p.MaxAttempts
+}
+
+// maxRetryAfter returns the longest Retry-After delay honored, falling back to the default.
+func (p *RetryPolicy) maxRetryAfter() time.Duration {
+	if p.MaxRetryAfter <= 0 {
+		return defaultRetryMaxRetryAfter
+	}
+	return p.MaxRetryAfter
+}
+
+// shouldRetry reports whether the outcome of an attempt must be retried.
+func (p *RetryPolicy) shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		if p.RetryOnError == nil {
+			return DefaultRetryOnError(err)
+		}
+		return p.RetryOnError(err)
+	}
+	if p.RetryOnStatus == nil {
+		return DefaultRetryOnStatus(resp.StatusCode)
+	}
+	return p.RetryOnStatus(resp.StatusCode)
+}
+
+// backoff computes the delay before the next attempt. retry is the 1-based retry number and previous the last delay.
+func (p *RetryPolicy) backoff(retry int, previous time.Duration) time.Duration {
+	initial := p.InitialBackoff
+	if initial <= 0 {
+		initial = defaultRetryInitialBackoff
+	}
+	maxBackoff := p.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultRetryMaxBackoff
+	}
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = defaultRetryMultiplier
+	}
+
+	switch p.Jitter {
+	case JitterDecorrelated:
+		if previous < initial {
+			previous = initial
+		}
+		upper := min(maxBackoff, 3*previous)
+		if upper <= initial {
+			return upper
+		}
+		return initial + rand.N(upper-initial+1)
+	case JitterFull:
+		return rand.N(exponentialBackoff(initial, maxBackoff, multiplier, retry) + 1)
+	default:
+		return exponentialBackoff(initial, maxBackoff, multiplier, retry)
+	}
+}
+
+// nextDelay returns the backoff delay and the wait before the next attempt, which is the Retry-After delay of
+// throttled responses. It reports false when the server asks to wait longer than MaxRetryAfter.
+func (p *RetryPolicy) nextDelay(resp *http.Response, retry int, previous time.Duration) (time.Duration, time.Duration, bool) {
+	delay := p.backoff(retry, previous)
+	retryAfter, throttled := throttleDelay(resp)
+	if !throttled {
+		return delay, delay, true
+	}
+	return delay, retryAfter, retryAfter <= p.maxRetryAfter()
+}
+
+// exponentialBackoff returns initial*multiplier^(retry-1) capped at maxBackoff.
+func exponentialBackoff(initial, maxBackoff time.Duration, multiplier float64, retry int) time.Duration {
+	delay := float64(initial) * math.Pow(multiplier, float64(retry-1))
+	if delay >= float64(maxBackoff) {
+		return maxBackoff
+	}
+	return time.Duration(delay)
+}
+
+// doWithRetry sends the request through the middleware chain, retrying it according to the retry policy.
+// It returns the last response together with the number of attempts made.
+func (r *HTTPClientCall) doWithRetry(ctx context.Context, req *http.Request) (*http.Response, int, error) {
+	doer := r.doer()
+	policy := r.retryPolicy
+	if policy == nil {
+		resp, err := doer.Do(req)
+		return resp, 1, err
+	}
+
+	var delay, wait time.Duration
+	for attempt := 1; ; attempt++ {
+		attemptReq, err := newAttemptRequest(req, attempt)
+		if err != nil {
+			return nil, attempt, err
+		}
+
+		resp, err := doer.Do(attemptReq)
+		if attempt >= policy.maxAttempts() || !policy.shouldRetry(resp, err) || !isReplayable(req) {
+			return resp, attempt, err
+		}
+
+		var honored bool
+		delay, wait, honored = policy.nextDelay(resp, attempt, delay)
+		if !honored || exceedsDeadline(ctx, wait) {
+			return resp, attempt, err
+		}
+
+		discardResponse(resp)
+		if err = sleepContext(ctx, wait); err != nil {
+			return nil, attempt, err
+		}
+	}
+}
+
+// ParseRetryAfter parses a Retry-After header value expressed either as delta-seconds or as an HTTP-date.
+func ParseRetryAfter(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	return max(time.Until(date), 0), true
+}
+
+// throttleDelay returns the Retry-After delay of a 429 or 503 response.
+func throttleDelay(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+	return ParseRetryAfter(resp.Header.Get(HeaderRetryAfter))
+}
+
+// exceedsDeadline reports whether waiting for d would go past the context deadline.
+func exceedsDeadline(ctx context.Context, d time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return ok && time.Until(deadline) < d
+}
+
+// newAttemptRequest returns the request to send on the given attempt, re-creating the body for retries. The request
+// context is kept, so that retries carry the values set by newRequest such as the route template.
+func newAttemptRequest(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 1 {
+		return req, nil
+	}
+	attemptReq := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		attemptReq.Body = body
+	}
+	return attemptReq, nil
+}
+
+// isReplayable reports whether the request body can be sent again.
+func isReplayable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// discardResponse drains and closes the body of a response that is not returned to the caller.
+func discardResponse(resp *http.Response) {
+	if resp == nil || resp.Body == nil {
+		return
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+}
+
+// sleepContext waits for the given duration or until the context is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
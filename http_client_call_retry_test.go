@@ -0,0 +1,341 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type SequenceHTTPClient struct {
+	Responses []*http.Response
+	Errors    []error
+	Bodies    []string
+	Calls     int
+}
+
+func (m *SequenceHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	index := m.Calls
+	m.Calls++
+	if req.Body != nil {
+		data, _ := io.ReadAll(req.Body)
+		m.Bodies = append(m.Bodies, string(data))
+	}
+	var resp *http.Response
+	if index < len(m.Responses) {
+		resp = m.Responses[index]
+	}
+	var err error
+	if index < len(m.Errors) {
+		err = m.Errors[index]
+	}
+	return resp, err
+}
+
+func newStatusResponse(statusCode int) *http.Response {
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     http.Header{},
+		Body:       io.NopCloser(bytes.NewBufferString("")),
+	}
+}
+
+type HTTPClientCallRetrySuite struct {
+	suite.Suite
+	policy *RetryPolicy
+}
+
+func (suite *HTTPClientCallRetrySuite) SetupTest() {
+	suite.policy = NewRetryPolicy()
+	suite.policy.InitialBackoff = time.Millisecond
+	suite.policy.MaxBackoff = 2 * time.Millisecond
+}
+
+func (suite *HTTPClientCallRetrySuite) TestNewRetryPolicy() {
+	policy := NewRetryPolicy()
+	suite.Equal(defaultRetryMaxAttempts, policy.MaxAttempts)
+	suite.Equal(defaultRetryInitialBackoff, policy.InitialBackoff)
+	suite.Equal(defaultRetryMaxBackoff, policy.MaxBackoff)
+	suite.Equal(defaultRetryMultiplier, policy.Multiplier)
+	suite.Equal(JitterFull, policy.Jitter)
+	suite.Equal(defaultRetryMaxRetryAfter, policy.MaxRetryAfter)
+}
+
+func (suite *HTTPClientCallRetrySuite) TestDefaultPredicates() {
+	suite.True(DefaultRetryOnStatus(http.StatusTooManyRequests))
+	suite.True(DefaultRetryOnStatus(http.StatusServiceUnavailable))
+	suite.False(DefaultRetryOnStatus(http.StatusOK))
+	suite.False(DefaultRetryOnStatus(http.StatusBadRequest))
+
+	suite.True(DefaultRetryOnError(errors.New("connection reset")))
+	suite.False(DefaultRetryOnError(context.Canceled))
+	suite.False(DefaultRetryOnError(context.DeadlineExceeded))
+}
+
+func (suite *HTTPClientCallRetrySuite) TestBackoff() {
+	policy := &RetryPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+		Jitter:         JitterNone,
+		MaxRetryAfter:  0,
+	}
+
+	suite.Run("grows exponentially without jitter", func() {
+		suite.Equal(100*time.Millisecond, policy.backoff(1, 0))
+		suite.Equal(200*time.Millisecond, policy.backoff(2, 0))
+		suite.Equal(400*time.Millisecond, policy.backoff(3, 0))
+		suite.Equal(time.Second, policy.backoff(10, 0))
+	})
+
+	suite.Run("full jitter stays between zero and the exponential delay", func() {
+		policy.Jitter = JitterFull
+		for range 50 {
+			delay := policy.backoff(3, 0)
+			suite.GreaterOrEqual(delay, time.Duration(0))
+			suite.LessOrEqual(delay, 400*time.Millisecond)
+		}
+	})
+
+	suite.Run("decorrelated jitter stays between the initial and three times the previous delay", func() {
+		policy.Jitter = JitterDecorrelated
+		for range 50 {
+			delay := policy.backoff(2, 200*time.Millisecond)
+			suite.GreaterOrEqual(delay, 100*time.Millisecond)
+			suite.LessOrEqual(delay, 600*time.Millisecond)
+		}
+		suite.LessOrEqual(policy.backoff(2, time.Hour), time.Second)
+	})
+}
+
+func (suite *HTTPClientCallRetrySuite) TestDo_RetriesStatus() {
+	suite.Run("retries retryable status codes and resends the body", func() {
+		doer := &SequenceHTTPClient{Responses: []*http.Response{
+			newStatusResponse(http.StatusServiceUnavailable),
+			newStatusResponse(http.StatusBadGateway),
+			newStatusResponse(http.StatusOK),
+		}}
+		call := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodPost).
+			Body(map[string]string{"key": "value"}).
+			Retry(suite.policy)
+
+		resp, err := call.Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Equal(http.StatusOK, resp.StatusCode)
+		suite.Equal(3, doer.Calls)
+		suite.Len(doer.Bodies, 3)
+		for _, body := range doer.Bodies {
+			suite.JSONEq(`{"key":"value"}`, body)
+		}
+	})
+
+	suite.Run("returns the last response when attempts are exhausted", func() {
+		doer := &SequenceHTTPClient{Responses: []*http.Response{
+			newStatusResponse(http.StatusServiceUnavailable),
+			newStatusResponse(http.StatusServiceUnavailable),
+			newStatusResponse(http.StatusServiceUnavailable),
+			newStatusResponse(http.StatusOK),
+		}}
+		call := NewHTTPClientCall("http://example.com", doer).Method(http.MethodGet).Retry(suite.policy)
+
+		resp, err := call.Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Equal(http.StatusServiceUnavailable, resp.StatusCode)
+		suite.Equal(3, doer.Calls)
+	})
+
+	suite.Run("keeps the route of the request on retries", func() {
+		doer := &SequenceHTTPClient{Responses: []*http.Response{
+			newStatusResponse(http.StatusServiceUnavailable),
+			newStatusResponse(http.StatusOK),
+		}}
+		var keys []string
+		record := func(next HTTPClientDoer) HTTPClientDoer {
+			return HTTPClientDoerFunc(func(req *http.Request) (*http.Response, error) {
+				keys = append(keys, RouteKey(req))
+				return next.Do(req)
+			})
+		}
+		resp, err := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodGet).
+			Path("/users/42").
+			Route("/users/{id}").
+			Use(record).
+			Retry(suite.policy).
+			Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Equal(http.StatusOK, resp.StatusCode)
+		suite.Equal([]string{"example.com/users/{id}", "example.com/users/{id}"}, keys)
+	})
+
+	suite.Run("does not retry non retryable status codes", func() {
+		doer := &SequenceHTTPClient{Responses: []*http.Response{
+			newStatusResponse(http.StatusBadRequest),
+			newStatusResponse(http.StatusOK),
+		}}
+		call := NewHTTPClientCall("http://example.com", doer).Method(http.MethodGet).Retry(suite.policy)
+
+		resp, err := call.Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Equal(http.StatusBadRequest, resp.StatusCode)
+		suite.Equal(1, doer.Calls)
+	})
+}
+
+func (suite *HTTPClientCallRetrySuite) TestDo_RetriesErrors() {
+	suite.Run("retries transport errors", func() {
+		doer := &SequenceHTTPClient{
+			Responses: []*http.Response{nil, newStatusResponse(http.StatusOK)},
+			Errors:    []error{errors.New("connection reset"), nil},
+		}
+		call := NewHTTPClientCall("http://example.com", doer).Method(http.MethodGet).Retry(suite.policy)
+
+		resp, err := call.Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Equal(http.StatusOK, resp.StatusCode)
+		suite.Equal(2, doer.Calls)
+	})
+
+	suite.Run("uses the custom error predicate", func() {
+		doer := &SequenceHTTPClient{
+			Responses: []*http.Response{nil, newStatusResponse(http.StatusOK)},
+			Errors:    []error{errors.New("fatal"), nil},
+		}
+		suite.policy.RetryOnError = func(error) bool { return false }
+		call := NewHTTPClientCall("http://example.com", doer).Method(http.MethodGet).Retry(suite.policy)
+
+		_, err := call.Do(context.Background())
+		suite.EqualError(err, "fatal")
+		suite.Equal(1, doer.Calls)
+	})
+
+	suite.Run("stops waiting when the context is cancelled", func() {
+		doer := &SequenceHTTPClient{Responses: []*http.Response{
+			newStatusResponse(http.StatusServiceUnavailable),
+			newStatusResponse(http.StatusOK),
+		}}
+		policy := NewRetryPolicy()
+		policy.InitialBackoff = time.Hour
+		policy.MaxBackoff = time.Hour
+		policy.Jitter = JitterNone
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+		call := NewHTTPClientCall("http://example.com", doer).Method(http.MethodGet).Retry(policy)
+
+		_, err := call.Do(ctx)
+		suite.ErrorIs(err, context.Canceled)
+		suite.Equal(1, doer.Calls)
+	})
+}
+
+func (suite *HTTPClientCallRetrySuite) TestParseRetryAfter() {
+	suite.Run("parses delta seconds", func() {
+		delay, ok := ParseRetryAfter("120")
+		suite.True(ok)
+		suite.Equal(120*time.Second, delay)
+	})
+
+	suite.Run("parses an HTTP date", func() {
+		date := time.Now().Add(30 * time.Second).UTC().Format(http.TimeFormat)
+		delay, ok := ParseRetryAfter(date)
+		suite.True(ok)
+		suite.InDelta(float64(30*time.Second), float64(delay), float64(2*time.Second))
+	})
+
+	suite.Run("returns zero for a date in the past", func() {
+		date := time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat)
+		delay, ok := ParseRetryAfter(date)
+		suite.True(ok)
+		suite.Zero(delay)
+	})
+
+	suite.Run("rejects invalid values", func() {
+		for _, value := range []string{"", "-1", "soon"} {
+			_, ok := ParseRetryAfter(value)
+			suite.False(ok, value)
+		}
+	})
+}
+
+func (suite *HTTPClientCallRetrySuite) TestDo_RetryAfter() {
+	suite.Run("waits the Retry-After delay of a throttled response", func() {
+		throttled := newStatusResponse(http.StatusTooManyRequests)
+		throttled.Header.Set(HeaderRetryAfter, "30")
+		delay, wait, honored := suite.policy.nextDelay(throttled, 1, 0)
+		suite.True(honored)
+		suite.Equal(30*time.Second, wait)
+		suite.LessOrEqual(delay, suite.policy.MaxBackoff)
+
+		delay, wait, honored = suite.policy.nextDelay(newStatusResponse(http.StatusBadGateway), 1, 0)
+		suite.True(honored)
+		suite.Equal(delay, wait)
+	})
+
+	suite.Run("retries a throttled response after the Retry-After delay", func() {
+		throttled := newStatusResponse(http.StatusTooManyRequests)
+		throttled.Header.Set(HeaderRetryAfter, "0")
+		doer := &SequenceHTTPClient{Responses: []*http.Response{throttled, newStatusResponse(http.StatusOK)}}
+		call := NewHTTPClientCall("http://example.com", doer).Method(http.MethodGet).Retry(suite.policy)
+
+		resp, err := call.Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Equal(http.StatusOK, resp.StatusCode)
+		suite.Equal(2, doer.Calls)
+	})
+
+	suite.Run("gives up when the wait exceeds the context deadline", func() {
+		throttled := newStatusResponse(http.StatusServiceUnavailable)
+		throttled.Header.Set(HeaderRetryAfter, "60")
+		doer := &SequenceHTTPClient{Responses: []*http.Response{throttled, newStatusResponse(http.StatusOK)}}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		call := NewHTTPClientCall("http://example.com", doer).Method(http.MethodGet).Retry(suite.policy)
+
+		resp, err := call.Do(ctx)
+		require.NoError(suite.T(), err)
+		suite.Equal(http.StatusServiceUnavailable, resp.StatusCode)
+		suite.NoError(ctx.Err())
+		suite.Equal(1, doer.Calls)
+	})
+
+	suite.Run("gives up when the Retry-After delay exceeds MaxRetryAfter", func() {
+		throttled := newStatusResponse(http.StatusTooManyRequests)
+		throttled.Header.Set(HeaderRetryAfter, "86400")
+		doer := &SequenceHTTPClient{Responses: []*http.Response{throttled, newStatusResponse(http.StatusOK)}}
+		suite.policy.MaxRetryAfter = time.Hour
+		call := NewHTTPClientCall("http://example.com", doer).Method(http.MethodGet).Retry(suite.policy)
+
+		resp, err := call.Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Equal(http.StatusTooManyRequests, resp.StatusCode)
+		suite.Equal(1, doer.Calls)
+	})
+
+	suite.Run("exposes the Retry-After delay on the response metadata", func() {
+		throttled := newStatusResponse(http.StatusTooManyRequests)
+		throttled.Header.Set(HeaderRetryAfter, "60")
+		throttled.Header.Set(HeaderContentType, MIMETextPlain)
+		doer := &SequenceHTTPClient{Responses: []*http.Response{throttled}}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		call := NewHTTPClientCall("http://example.com", doer).Method(http.MethodGet).Retry(suite.policy)
+
+		var body string
+		resp, err := call.DoWithUnmarshal(ctx, &body)
+		var httpError *HTTPError
+		suite.ErrorAs(err, &httpError)
+		suite.Equal(http.StatusTooManyRequests, resp.StatusCode)
+		suite.Equal(60*time.Second, resp.RetryAfter)
+	})
+}
+
+func TestHTTPClientCallRetrySuite(t *testing.T) {
+	suite.Run(t, new(HTTPClientCallRetrySuite))
+}
@@ -0,0 +1,198 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultEventStreamRetry is the reconnection delay used until the server sends a retry field.
+const defaultEventStreamRetry = 3 * time.Second
+
+// ServerSentEvent is an event received from a text/event-stream response.
+type ServerSentEvent struct {
+	// ID is the last event ID of the stream when the event was dispatched.
+	ID string `json:"id"`
+	// Event is the event type. It defaults to "message".
+	Event string `json:"event"`
+	// Data is the event payload. Multiple data lines are joined with a newline.
+	Data string `json:"data"`
+	// Retry is the reconnection delay sent with the event, or zero when the event does not set one.
+	Retry time.Duration `json:"retry"`
+}
+
+// EventStreamRetry sets the delay before reconnecting to an event stream until the server sends a retry field.
+func (r *HTTPClientCall) EventStreamRetry(retry time.Duration) *HTTPClientCall {
+	r.eventStreamRetry = retry
+	return r
+}
+
+// SubscribeEvents opens a Server-Sent Events stream and calls fn for every event received.
+// When the stream ends or the connection fails, it reconnects after the retry interval sent by the server, resuming
+// from the last event with the Last-Event-ID header. It returns when the context is done, when fn returns an error,
+// when the server answers 204 No Content, or when the server answers with an error status or another content type.
+func (r *HTTPClientCall) SubscribeEvents(ctx context.Context, fn func(event ServerSentEvent) error) error {
+	if r.method == "" {
+		r.method = http.MethodGet
+	}
+	parser := &eventStreamParser{
+		data:        strings.Builder{},
+		lastEventID: "",
+		eventType:   "",
+		eventRetry:  0,
+		retry:       r.eventStreamRetry,
+	}
+	if parser.retry <= 0 {
+		parser.retry = defaultEventStreamRetry
+	}
+
+	for {
+		reconnect, err := r.readEventStream(ctx, parser, fn)
+		if !reconnect {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err = sleepContext(ctx, parser.retry); err != nil {
+			return err
+		}
+	}
+}
+
+// readEventStream opens one connection to the event stream and parses it until it ends.
+// It reports whether the client must reconnect, together with the error that ended the connection.
+func (r *HTTPClientCall) readEventStream(ctx context.Context, parser *eventStreamParser, fn func(ServerSentEvent) error) (bool, error) {
+	req, err := r.newRequest(ctx)
+	if err != nil {
+		return false, err
+	}
+	if req.Header.Get(HeaderAccept) == "" {
+		req.Header.Set(HeaderAccept, MIMETextEventStream)
+	}
+	req.Header.Set(HeaderCacheControl, "no-cache")
+	if parser.lastEventID != "" {
+		req.Header.Set(HeaderLastEventID, parser.lastEventID)
+	}
+
+	resp, _, err := r.send(ctx, req)
+	if err != nil {
+		return true, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return false, nil
+	}
+	if !r.isSuccessStatus(resp.StatusCode) {
+		return false, r.newHTTPError(resp)
+	}
+	contentType := resp.Header.Get(HeaderContentType)
+	if mediaType, ok := parseMediaType(contentType); !ok || mediaType != MIMETextEventStream {
+		return false, fmt.Errorf("unsupported content type: %s", contentType)
+	}
+
+	var handlerErr error
+	err = parser.parse(resp.Body, func(event ServerSentEvent) error {
+		handlerErr = fn(event)
+		return handlerErr
+	})
+	if handlerErr != nil {
+		return false, handlerErr
+	}
+	return true, err
+}
+
+// eventStreamParser parses a text/event-stream body. The last event ID and retry interval are kept across
+// connections, while the event being built is discarded when a connection ends.
+type eventStreamParser struct {
+	data        strings.Builder
+	lastEventID string
+	eventType   string
+	eventRetry  time.Duration
+	retry       time.Duration
+}
+
+// parse reads events from r and calls fn for each one until the stream ends or fn returns an error.
+func (p *eventStreamParser) parse(r io.Reader, fn func(ServerSentEvent) error) error {
+	p.resetEvent()
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if err = p.processLine(strings.TrimRight(line, "\r\n"), fn); err != nil {
+			return err
+		}
+	}
+}
+
+// processLine processes a single line, dispatching the pending event on a blank line.
+func (p *eventStreamParser) processLine(line string, fn func(ServerSentEvent) error) error {
+	if line == "" {
+		defer p.resetEvent()
+		if p.data.Len() == 0 {
+			return nil
+		}
+		event := ServerSentEvent{
+			ID:    p.lastEventID,
+			Event: p.eventType,
+			Data:  strings.TrimSuffix(p.data.String(), "\n"),
+			Retry: p.eventRetry,
+		}
+		if event.Event == "" {
+			event.Event = "message"
+		}
+		return fn(event)
+	}
+
+	field, value := parseEventStreamLine(line)
+	switch field {
+	case "event":
+		p.eventType = value
+	case "data":
+		p.data.WriteString(value)
+		p.data.WriteByte('\n')
+	case "id":
+		if !strings.ContainsRune(value, 0) {
+			p.lastEventID = value
+		}
+	case "retry":
+		if milliseconds, err := strconv.Atoi(value); err == nil && milliseconds >= 0 {
+			p.eventRetry = time.Duration(milliseconds) * time.Millisecond
+			p.retry = p.eventRetry
+		}
+	}
+	return nil
+}
+
+// resetEvent discards the event being built.
+func (p *eventStreamParser) resetEvent() {
+	p.data.Reset()
+	p.eventType = ""
+	p.eventRetry = 0
+}
+
+// parseEventStreamLine splits an event stream line into its field and value. Comment lines return an empty field.
+func parseEventStreamLine(line string) (string, string) {
+	if strings.HasPrefix(line, ":") {
+		return "", ""
+	}
+	field, value, found := strings.Cut(line, ":")
+	if !found {
+		return field, ""
+	}
+	return field, strings.TrimPrefix(value, " ")
+}
@@ -0,0 +1,162 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type HTTPClientCallSSESuite struct {
+	suite.Suite
+}
+
+func (suite *HTTPClientCallSSESuite) parse(stream string) ([]ServerSentEvent, *eventStreamParser, error) {
+	parser := &eventStreamParser{retry: defaultEventStreamRetry}
+	var events []ServerSentEvent
+	err := parser.parse(strings.NewReader(stream), func(event ServerSentEvent) error {
+		events = append(events, event)
+		return nil
+	})
+	return events, parser, err
+}
+
+func (suite *HTTPClientCallSSESuite) TestParser() {
+	suite.Run("parses fields, comments and multi-line data", func() {
+		stream := ": comment\n" +
+			"event: update\n" +
+			"id: 1\n" +
+			"data: first line\n" +
+			"data:second line\n" +
+			"retry: 1500\n" +
+			"\n" +
+			"data: plain\r\n" +
+			"\r\n"
+		events, parser, err := suite.parse(stream)
+		require.NoError(suite.T(), err)
+		suite.Equal([]ServerSentEvent{
+			{ID: "1", Event: "update", Data: "first line\nsecond line", Retry: 1500 * time.Millisecond},
+			{ID: "1", Event: "message", Data: "plain"},
+		}, events)
+		suite.Equal("1", parser.lastEventID)
+		suite.Equal(1500*time.Millisecond, parser.retry)
+	})
+
+	suite.Run("ignores events without data and incomplete events", func() {
+		events, parser, err := suite.parse("id: 7\n\ndata: incomplete\n")
+		require.NoError(suite.T(), err)
+		suite.Empty(events)
+		suite.Equal("7", parser.lastEventID)
+	})
+
+	suite.Run("ignores invalid retry values and ids with NULL", func() {
+		events, parser, err := suite.parse("retry: soon\nid: a\x00b\ndata: x\n\n")
+		require.NoError(suite.T(), err)
+		suite.Len(events, 1)
+		suite.Empty(parser.lastEventID)
+		suite.Equal(defaultEventStreamRetry, parser.retry)
+	})
+}
+
+func (suite *HTTPClientCallSSESuite) TestSubscribeEvents() {
+	suite.Run("reconnects with the last event id and the server retry interval", func() {
+		var mu sync.Mutex
+		var lastEventIDs []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			lastEventIDs = append(lastEventIDs, r.Header.Get(HeaderLastEventID))
+			connection := len(lastEventIDs)
+			mu.Unlock()
+
+			suite.Equal(MIMETextEventStream, r.Header.Get(HeaderAccept))
+			if connection > 2 {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			w.Header().Set(HeaderContentType, MIMETextEventStream)
+			_, _ = fmt.Fprintf(w, "retry: 10\nid: %d\ndata: event %d\n\n", connection, connection)
+		}))
+		defer server.Close()
+
+		var events []ServerSentEvent
+		err := NewHTTPClientCall(server.URL, server.Client()).
+			SubscribeEvents(context.Background(), func(event ServerSentEvent) error {
+				events = append(events, event)
+				return nil
+			})
+		require.NoError(suite.T(), err)
+		suite.Equal([]string{"", "1", "2"}, lastEventIDs)
+		require.Len(suite.T(), events, 2)
+		suite.Equal("event 1", events[0].Data)
+		suite.Equal("2", events[1].ID)
+	})
+
+	suite.Run("stops when the handler returns an error", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set(HeaderContentType, MIMETextEventStream)
+			_, _ = fmt.Fprint(w, "data: one\n\ndata: two\n\n")
+		}))
+		defer server.Close()
+
+		stop := errors.New("stop")
+		var events []ServerSentEvent
+		err := NewHTTPClientCall(server.URL, server.Client()).
+			SubscribeEvents(context.Background(), func(event ServerSentEvent) error {
+				events = append(events, event)
+				return stop
+			})
+		suite.ErrorIs(err, stop)
+		suite.Len(events, 1)
+	})
+
+	suite.Run("stops when the context is cancelled", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(HeaderContentType, MIMETextEventStream)
+			_, _ = fmt.Fprint(w, "data: one\n\n")
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}))
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		err := NewHTTPClientCall(server.URL, server.Client()).
+			EventStreamRetry(time.Millisecond).
+			SubscribeEvents(ctx, func(ServerSentEvent) error {
+				cancel()
+				return nil
+			})
+		suite.ErrorIs(err, context.Canceled)
+	})
+
+	suite.Run("fails on error statuses and other content types", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/json" {
+				w.Header().Set(HeaderContentType, MIMEApplicationJSON)
+				return
+			}
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		noop := func(ServerSentEvent) error { return nil }
+		err := NewHTTPClientCall(server.URL, server.Client()).SubscribeEvents(context.Background(), noop)
+		var httpError *HTTPError
+		suite.ErrorAs(err, &httpError)
+		suite.Equal(http.StatusUnauthorized, httpError.StatusCode)
+
+		err = NewHTTPClientCall(server.URL, server.Client()).Path("/json").SubscribeEvents(context.Background(), noop)
+		suite.EqualError(err, "unsupported content type: application/json")
+	})
+}
+
+func TestHTTPClientCallSSESuite(t *testing.T) {
+	suite.Run(t, new(HTTPClientCallSSESuite))
+}
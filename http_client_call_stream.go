@@ -0,0 +1,141 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DoStream executes the HTTP request and decodes the response body element by element, calling fn for each item.
+// Newline-delimited JSON (application/x-ndjson, JSON Lines) is decoded value by value and top-level arrays of
+// application/json and +json media types element by element, without buffering the whole body. A JSON value other
+// than an array is passed to fn as a single item. The body of HEAD and 204 No Content responses is not decoded.
+// Decoding stops at the first error returned by fn, which is returned to the caller.
+func DoStream[T any](ctx context.Context, call *HTTPClientCall, fn func(item T) error) (*HTTPClientCallResponse, error) {
+	start := time.Now()
+	requestURL := call.constructURL()
+	resp, attempts, err := call.do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	httpClientCallResponse := newHTTPClientCallResponse(resp, requestURL, attempts)
+	defer func() {
+		httpClientCallResponse.Duration = time.Since(start)
+	}()
+	if !call.isSuccessStatus(resp.StatusCode) {
+		return httpClientCallResponse, call.newHTTPError(resp)
+	}
+	if call.method == http.MethodHead || resp.StatusCode == http.StatusNoContent {
+		return httpClientCallResponse, nil
+	}
+
+	if err = call.decodeResponseContent(resp); err != nil {
+		return nil, err
+	}
+	if err = decodeStream(resp.Header.Get(HeaderContentType), resp.Body, fn); err != nil {
+		return nil, err
+	}
+	return httpClientCallResponse, nil
+}
+
+// decodeStream decodes the body element by element according to its content type.
+func decodeStream[T any](contentType string, r io.Reader, fn func(item T) error) error {
+	mediaType, _ := parseMediaType(contentType)
+	switch {
+	case mediaType == MIMEApplicationNDJSON || mediaType == MIMEApplicationJSONLines ||
+		mediaType == MIMEApplicationXJSONLines:
+		return decodeJSONSequence(r, fn)
+	case mediaType == MIMEApplicationJSON || strings.HasSuffix(mediaType, "+json"):
+		return decodeJSONArray(r, fn)
+	default:
+		return fmt.Errorf("unsupported content type: %s", contentType)
+	}
+}
+
+// decodeJSONSequence decodes a stream of whitespace-separated JSON values, such as NDJSON or JSON Lines.
+func decodeJSONSequence[T any](r io.Reader, fn func(item T) error) error {
+	decoder := json.NewDecoder(r)
+	for {
+		var item T
+		if err := decoder.Decode(&item); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+}
+
+// decodeJSONArray decodes the elements of a top-level JSON array one at a time. A body that is not an array is
+// decoded as a single item.
+func decodeJSONArray[T any](r io.Reader, fn func(item T) error) error {
+	reader := bufio.NewReader(r)
+	first, err := peekNonSpace(reader)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	}
+	if first != '[' {
+		return decodeJSONValue(reader, fn)
+	}
+
+	decoder := json.NewDecoder(reader)
+	if _, err = decoder.Token(); err != nil {
+		return err
+	}
+	for decoder.More() {
+		var item T
+		if err = decoder.Decode(&item); err != nil {
+			return err
+		}
+		if err = fn(item); err != nil {
+			return err
+		}
+	}
+	_, err = decoder.Token()
+	return err
+}
+
+// decodeJSONValue decodes a single JSON value, failing when other data follows it.
+func decodeJSONValue[T any](r io.Reader, fn func(item T) error) error {
+	decoder := json.NewDecoder(r)
+	var item T
+	if err := decoder.Decode(&item); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return errors.New("unexpected data after the JSON value")
+	}
+	return fn(item)
+}
+
+// peekNonSpace discards leading JSON whitespace and returns the next byte without consuming it.
+func peekNonSpace(reader *bufio.Reader) (byte, error) {
+	for {
+		b, err := reader.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		switch b[0] {
+		case ' ', '\t', '\n', '\r':
+			_, _ = reader.ReadByte()
+		default:
+			return b[0], nil
+		}
+	}
+}
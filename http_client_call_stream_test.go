@@ -0,0 +1,161 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type streamItem struct {
+	ID int `json:"id"`
+}
+
+type HTTPClientCallStreamSuite struct {
+	suite.Suite
+}
+
+func (suite *HTTPClientCallStreamSuite) call(contentType, body string) *HTTPClientCall {
+	doer := &MockHTTPClient{Response: &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{HeaderContentType: []string{contentType}},
+		Body:       io.NopCloser(bytes.NewBufferString(body)),
+	}}
+	return NewHTTPClientCall("http://example.com", doer).Method(http.MethodGet)
+}
+
+func (suite *HTTPClientCallStreamSuite) collect(call *HTTPClientCall) ([]streamItem, *HTTPClientCallResponse, error) {
+	var items []streamItem
+	resp, err := DoStream(context.Background(), call, func(item streamItem) error {
+		items = append(items, item)
+		return nil
+	})
+	return items, resp, err
+}
+
+func (suite *HTTPClientCallStreamSuite) TestDoStream() {
+	expected := []streamItem{{ID: 1}, {ID: 2}, {ID: 3}}
+
+	suite.Run("decodes newline-delimited JSON", func() {
+		for _, contentType := range []string{MIMEApplicationNDJSON, MIMEApplicationJSONLines, MIMEApplicationXJSONLines} {
+			items, resp, err := suite.collect(suite.call(contentType, "{\"id\":1}\n{\"id\":2}\n\n{\"id\":3}\n"))
+			require.NoError(suite.T(), err)
+			suite.Equal(http.StatusOK, resp.StatusCode)
+			suite.Equal(expected, items)
+		}
+	})
+
+	suite.Run("decodes top-level JSON arrays element by element", func() {
+		items, _, err := suite.collect(suite.call(MIMEApplicationJSONCharsetUTF8, ` [{"id":1}, {"id":2}, {"id":3}]`))
+		require.NoError(suite.T(), err)
+		suite.Equal(expected, items)
+	})
+
+	suite.Run("accepts content types with invalid parameters", func() {
+		items, _, err := suite.collect(suite.call(MIMEApplicationNDJSON+"; charset", "{\"id\":1}\n"))
+		require.NoError(suite.T(), err)
+		suite.Equal([]streamItem{{ID: 1}}, items)
+	})
+
+	suite.Run("decodes a single JSON value as one item", func() {
+		items, _, err := suite.collect(suite.call(MIMEApplicationJSON, `{"id":1}`))
+		require.NoError(suite.T(), err)
+		suite.Equal([]streamItem{{ID: 1}}, items)
+	})
+
+	suite.Run("rejects JSON values followed by other data", func() {
+		items, _, err := suite.collect(suite.call(MIMEApplicationJSON, `{"id":1} {"id":2}`))
+		suite.EqualError(err, "unexpected data after the JSON value")
+		suite.Empty(items)
+	})
+
+	suite.Run("decodes +json media types", func() {
+		items, _, err := suite.collect(suite.call("application/vnd.api+json", `[{"id":1}, {"id":2}, {"id":3}]`))
+		require.NoError(suite.T(), err)
+		suite.Equal(expected, items)
+	})
+
+	suite.Run("does not decode HEAD and 204 No Content responses", func() {
+		items, resp, err := suite.collect(suite.call("", "").Method(http.MethodHead))
+		require.NoError(suite.T(), err)
+		suite.Equal(http.StatusOK, resp.StatusCode)
+		suite.Empty(items)
+
+		doer := &MockHTTPClient{Response: newStatusResponse(http.StatusNoContent)}
+		items, resp, err = suite.collect(NewHTTPClientCall("http://example.com", doer).Method(http.MethodGet))
+		require.NoError(suite.T(), err)
+		suite.Equal(http.StatusNoContent, resp.StatusCode)
+		suite.Empty(items)
+	})
+
+	suite.Run("handles empty bodies", func() {
+		items, _, err := suite.collect(suite.call(MIMEApplicationJSON, ""))
+		require.NoError(suite.T(), err)
+		suite.Empty(items)
+	})
+
+	suite.Run("returns decoding errors", func() {
+		items, _, err := suite.collect(suite.call(MIMEApplicationJSON, `[{"id":1}, {"id":`))
+		suite.Error(err)
+		suite.Equal([]streamItem{{ID: 1}}, items)
+	})
+
+	suite.Run("stops at the first callback error", func() {
+		stop := errors.New("stop")
+		var items []streamItem
+		_, err := DoStream(context.Background(), suite.call(MIMEApplicationNDJSON, "{\"id\":1}\n{\"id\":2}\n"),
+			func(item streamItem) error {
+				items = append(items, item)
+				return stop
+			})
+		suite.ErrorIs(err, stop)
+		suite.Len(items, 1)
+	})
+
+	suite.Run("rejects unsupported content types", func() {
+		_, _, err := suite.collect(suite.call(MIMETextPlain, "text"))
+		suite.EqualError(err, "unsupported content type: text/plain")
+	})
+
+	suite.Run("returns an HTTPError for error responses", func() {
+		call := suite.call(MIMEApplicationJSON, `{"message":"boom"}`)
+		call.client.(*MockHTTPClient).Response.StatusCode = http.StatusInternalServerError
+		_, resp, err := suite.collect(call)
+		var httpError *HTTPError
+		suite.ErrorAs(err, &httpError)
+		suite.Equal(http.StatusInternalServerError, resp.StatusCode)
+	})
+}
+
+func (suite *HTTPClientCallStreamSuite) TestDecodeJSONArray_DoesNotBuffer() {
+	suite.Run("delivers items before the whole body is read", func() {
+		pr, pw := io.Pipe()
+		go func() {
+			_, _ = io.WriteString(pw, `[{"id":1},`)
+		}()
+
+		first := make(chan streamItem, 1)
+		done := make(chan error, 1)
+		go func() {
+			done <- decodeJSONArray(pr, func(item streamItem) error {
+				first <- item
+				return nil
+			})
+		}()
+
+		suite.Equal(streamItem{ID: 1}, <-first)
+		_, _ = io.Copy(pw, strings.NewReader(`{"id":2}]`))
+		_ = pw.Close()
+		suite.NoError(<-done)
+	})
+}
+
+func TestHTTPClientCallStreamSuite(t *testing.T) {
+	suite.Run(t, new(HTTPClientCallStreamSuite))
+}
@@ -43,8 +43,31 @@ func (suite *HTTPClientCallSuite) TestNewHTTPClientCall() {
 		suite.True(call.isEncodeURL)
 		suite.Empty(call.method)
 		suite.Nil(call.headers)
+		suite.Nil(call.defaultHeaders)
 		suite.Nil(call.body)
+		suite.Nil(call.encoder)
+		suite.Nil(call.encoders)
+		suite.Nil(call.decoder)
+		suite.Nil(call.decoders)
+		suite.Nil(call.circuitBreaker)
+		suite.Nil(call.rateLimiter)
+		suite.Nil(call.bulkhead)
+		suite.Empty(call.route)
+		suite.Nil(call.getBody)
+		suite.Nil(call.onUploadProgress)
+		suite.Zero(call.eventStreamRetry)
+		suite.Nil(call.compression)
+		suite.Nil(call.decompressors)
+		suite.Zero(call.maxDecompressedSize)
+		suite.Nil(call.retryPolicy)
+		suite.Nil(call.middlewares)
+		suite.Nil(call.errorBody)
+		suite.Equal(defaultSuccessStatusMin, call.successStatusMin)
+		suite.Equal(defaultSuccessStatusMax, call.successStatusMax)
+		suite.Nil(call.allowedMethods)
 		suite.False(call.gzipCompress)
+		suite.False(call.skipMiddlewares)
+		suite.False(call.keepRawBody)
 	})
 
 	suite.Run("panics on nil client", func() {
@@ -180,6 +203,35 @@ func (suite *HTTPClientCallSuite) TestHTTPClientCall_DoWithUnmarshal_HTML() {
 	})
 }
 
+func (suite *HTTPClientCallSuite) TestHTTPClientCall_DoWithUnmarshal_XML() {
+	suite.Run("executes HTTP request and unmarshals XML response", func() {
+		mockResponse := &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewBufferString(`<item><key>value</key></item>`)),
+			Header:     http.Header{"Content-Type": []string{"application/xml; charset=UTF-8"}},
+		}
+		suite.client.Response = mockResponse
+		suite.client.Err = nil
+
+		call := &HTTPClientCall{
+			client: suite.client,
+			host:   suite.host,
+			method: http.MethodGet,
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		var responseBody struct {
+			Key string `xml:"key"`
+		}
+		resp, err := call.DoWithUnmarshal(ctx, &responseBody)
+		require.NoError(suite.T(), err)
+		suite.Equal(mockResponse.StatusCode, resp.StatusCode)
+		suite.Equal("value", responseBody.Key)
+	})
+}
+
 func (suite *HTTPClientCallSuite) TestHTTPClientCall_DoWithUnmarshal_String() {
 	suite.Run("executes HTTP request and unmarshals plain text response", func() {
 		mockResponse := &http.Response{
@@ -207,6 +259,80 @@ func (suite *HTTPClientCallSuite) TestHTTPClientCall_DoWithUnmarshal_String() {
 	})
 }
 
+func (suite *HTTPClientCallSuite) TestHTTPClientCall_DoWithUnmarshal_ResponseMetadata() {
+	suite.Run("returns headers, URL, protocol, attempts and duration", func() {
+		finalURL, _ := url.Parse("http://example.com/redirected")
+		mockResponse := &http.Response{
+			StatusCode: http.StatusOK,
+			Proto:      "HTTP/2.0",
+			Body:       io.NopCloser(bytes.NewBufferString(`{"key":"value"}`)),
+			Header: http.Header{
+				"Content-Type": []string{"application/json"},
+				"Etag":         []string{`"v1"`},
+			},
+			Request: &http.Request{URL: finalURL},
+		}
+		suite.client.Response = mockResponse
+		suite.client.Err = nil
+
+		call := NewHTTPClientCall(suite.host, suite.client).Method(http.MethodGet).Path("/original")
+
+		var responseBody map[string]string
+		resp, err := call.DoWithUnmarshal(context.Background(), &responseBody)
+		require.NoError(suite.T(), err)
+		suite.Equal(`"v1"`, resp.Header.Get("ETag"))
+		suite.Equal("http://example.com/redirected", resp.URL)
+		suite.Equal("HTTP/2.0", resp.Proto)
+		suite.Equal(1, resp.Attempts)
+		suite.Positive(resp.Duration)
+		suite.Nil(resp.RawBody)
+	})
+
+	suite.Run("falls back to the request URL and keeps the raw body", func() {
+		mockResponse := &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewBufferString(`{"key":"value"}`)),
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+		}
+		suite.client.Response = mockResponse
+		suite.client.Err = nil
+
+		call := NewHTTPClientCall(suite.host, suite.client).
+			Method(http.MethodGet).
+			Path("/path").
+			Params(url.Values{"page": []string{"2"}}).
+			KeepRawBody(true)
+
+		var responseBody map[string]string
+		resp, err := call.DoWithUnmarshal(context.Background(), &responseBody)
+		require.NoError(suite.T(), err)
+		suite.Equal("http://example.com/path?page=2", resp.URL)
+		suite.Equal(`{"key":"value"}`, string(resp.RawBody))
+		suite.Equal("value", responseBody["key"])
+	})
+
+	suite.Run("counts retried attempts", func() {
+		doer := &SequenceHTTPClient{Responses: []*http.Response{
+			newStatusResponse(http.StatusServiceUnavailable),
+			{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"text/plain"}},
+				Body:       io.NopCloser(bytes.NewBufferString("ok")),
+			},
+		}}
+		policy := NewRetryPolicy()
+		policy.InitialBackoff = time.Millisecond
+
+		var responseBody string
+		resp, err := NewHTTPClientCall(suite.host, doer).
+			Method(http.MethodGet).
+			Retry(policy).
+			DoWithUnmarshal(context.Background(), &responseBody)
+		require.NoError(suite.T(), err)
+		suite.Equal(2, resp.Attempts)
+	})
+}
+
 func (suite *HTTPClientCallSuite) TestHTTPClientCall_validateHTTPMethod() {
 	call := &HTTPClientCall{}
 
@@ -216,7 +342,11 @@ func (suite *HTTPClientCallSuite) TestHTTPClientCall_validateHTTPMethod() {
 	})
 
 	suite.Run("validates allowed methods", func() {
-		validMethods := []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch}
+		validMethods := []string{
+			http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch,
+			http.MethodHead, http.MethodOptions, http.MethodConnect, http.MethodTrace,
+			PROPFIND, PROPPATCH, MKCOL, COPY, MOVE, LOCK, UNLOCK, REPORT,
+		}
 		for _, method := range validMethods {
 			suite.Run(method, func() {
 				call.Method(method)
@@ -231,6 +361,47 @@ func (suite *HTTPClientCallSuite) TestHTTPClientCall_validateHTTPMethod() {
 		err := call.validateHTTPMethod()
 		suite.EqualError(err, errorMethodNotAllowed)
 	})
+
+	suite.Run("returns error for a method that is not a token", func() {
+		call.Method("GET /")
+		err := call.validateHTTPMethod()
+		suite.EqualError(err, errorInvalidMethod)
+	})
+
+	suite.Run("uses the allow-list policy", func() {
+		call.AllowedMethods("PURGE", http.MethodGet)
+
+		call.Method("PURGE")
+		suite.NoError(call.validateHTTPMethod())
+		call.Method(http.MethodPost)
+		suite.EqualError(call.validateHTTPMethod(), errorMethodNotAllowed)
+	})
+
+	suite.Run("inherits the allow-list policy from the client", func() {
+		c := NewHTTPClient(suite.host, suite.client, WithAllowedMethods("PURGE"))
+		suite.NoError(c.NewCall().Method("PURGE").validateHTTPMethod())
+		suite.EqualError(c.NewCall().Method(http.MethodGet).validateHTTPMethod(), errorMethodNotAllowed)
+	})
+}
+
+func (suite *HTTPClientCallSuite) TestHTTPClientCall_DoWithUnmarshal_Head() {
+	suite.Run("does not decode the body of a HEAD response", func() {
+		suite.client.Response = &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       http.NoBody,
+			Header:     http.Header{"Content-Type": []string{"application/json"}, "Content-Length": []string{"42"}},
+		}
+		suite.client.Err = nil
+
+		var responseBody map[string]string
+		resp, err := NewHTTPClientCall(suite.host, suite.client).
+			Method(http.MethodHead).
+			DoWithUnmarshal(context.Background(), &responseBody)
+		require.NoError(suite.T(), err)
+		suite.Equal(http.StatusOK, resp.StatusCode)
+		suite.Equal("42", resp.Header.Get("Content-Length"))
+		suite.Nil(responseBody)
+	})
 }
 
 func (suite *HTTPClientCallSuite) TestHTTPClientCall_Do_ErrorCases() {
@@ -0,0 +1,155 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// GetBody sets a factory returning a fresh copy of a streamed request body, so that the request can be retried.
+// When no body is set, the factory also provides the body of the first attempt.
+func (r *HTTPClientCall) GetBody(getBody func() (io.ReadCloser, error)) *HTTPClientCall {
+	r.getBody = getBody
+	return r
+}
+
+// OnUploadProgress sets a callback invoked as the request body is sent, with the number of bytes sent so far and the
+// total size of the body, or -1 when it is unknown.
+func (r *HTTPClientCall) OnUploadProgress(onUploadProgress func(sent, total int64)) *HTTPClientCall {
+	r.onUploadProgress = onUploadProgress
+	return r
+}
+
+// setStreamBody streams reader as the request body, without buffering it.
+// Plain io.Reader values that implement io.Seeker are rewound for retries; io.ReadCloser values are closed once sent
+// and can only be retried through GetBody. Streamed bodies are always compressed when request compression is enabled,
+// since their size is unknown.
+func (r *HTTPClientCall) setStreamBody(req *http.Request, reader io.Reader) {
+	if r.headerValue(HeaderContentType) == "" {
+		req.Header.Set(HeaderContentType, MIMEOctetStream)
+	}
+
+	body, ok := reader.(io.ReadCloser)
+	if !ok {
+		body = io.NopCloser(reader)
+	}
+	req.Body = body
+	req.ContentLength = streamLength(reader)
+	req.GetBody = r.getBody
+	if req.GetBody == nil && !ok {
+		req.GetBody = seekableGetBody(reader)
+	}
+
+	if compression := r.requestCompression(); compression != nil {
+		req.Header.Set(HeaderContentEncoding, compression.Compressor.Encoding())
+		req.Body = compressStream(compression, req.Body)
+		req.ContentLength = -1
+		if getBody := req.GetBody; getBody != nil {
+			req.GetBody = func() (io.ReadCloser, error) {
+				body, err := getBody()
+				if err != nil {
+					return nil, err
+				}
+				return compressStream(compression, body), nil
+			}
+		}
+	}
+}
+
+// streamLength returns the length of readers whose size is known in advance, or -1.
+func streamLength(reader io.Reader) int64 {
+	switch v := reader.(type) {
+	case *bytes.Buffer:
+		return int64(v.Len())
+	case *bytes.Reader:
+		return int64(v.Len())
+	case *strings.Reader:
+		return int64(v.Len())
+	default:
+		return -1
+	}
+}
+
+// seekableGetBody returns a GetBody function that rewinds reader to its current offset, or nil if it cannot seek.
+func seekableGetBody(reader io.Reader) func() (io.ReadCloser, error) {
+	seeker, ok := reader.(io.Seeker)
+	if !ok {
+		return nil
+	}
+	offset, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil
+	}
+	return func() (io.ReadCloser, error) {
+		if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return io.NopCloser(reader), nil
+	}
+}
+
+// compressStream returns a reader that compresses body on the fly through an io.Pipe.
+func compressStream(compression *RequestCompression, body io.ReadCloser) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		w, err := compression.Compressor.NewWriter(pw, compression.Level)
+		if err == nil {
+			_, err = io.Copy(w, body)
+			if errClose := w.Close(); err == nil {
+				err = errClose
+			}
+		}
+		_ = body.Close()
+		_ = pw.CloseWithError(err)
+	}()
+	return pr
+}
+
+// progressReader reports the number of bytes read from the request body.
+type progressReader struct {
+	body       io.ReadCloser
+	onProgress func(sent, total int64)
+	sent       int64
+	total      int64
+}
+
+// Read reads from the body and reports the progress.
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.body.Read(b)
+	if n > 0 {
+		p.sent += int64(n)
+		p.onProgress(p.sent, p.total)
+	}
+	return n, err
+}
+
+// Close closes the body.
+func (p *progressReader) Close() error {
+	return p.body.Close()
+}
+
+// trackUploadProgress wraps the request body, and the bodies created for retries, to report the upload progress.
+func (r *HTTPClientCall) trackUploadProgress(req *http.Request) {
+	if r.onUploadProgress == nil || req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+	total := req.ContentLength
+	if total <= 0 {
+		total = -1
+	}
+	newProgressReader := func(body io.ReadCloser) io.ReadCloser {
+		return &progressReader{body: body, onProgress: r.onUploadProgress, sent: 0, total: total}
+	}
+
+	req.Body = newProgressReader(req.Body)
+	if getBody := req.GetBody; getBody != nil {
+		req.GetBody = func() (io.ReadCloser, error) {
+			body, err := getBody()
+			if err != nil {
+				return nil, err
+			}
+			return newProgressReader(body), nil
+		}
+	}
+}
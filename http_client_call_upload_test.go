@@ -0,0 +1,179 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type HTTPClientCallUploadSuite struct {
+	suite.Suite
+	policy *RetryPolicy
+}
+
+func (suite *HTTPClientCallUploadSuite) SetupTest() {
+	suite.policy = NewRetryPolicy()
+	suite.policy.InitialBackoff = time.Millisecond
+}
+
+func (suite *HTTPClientCallUploadSuite) retryingDoer() *SequenceHTTPClient {
+	return &SequenceHTTPClient{Responses: []*http.Response{
+		newStatusResponse(http.StatusServiceUnavailable),
+		newStatusResponse(http.StatusOK),
+	}}
+}
+
+func (suite *HTTPClientCallUploadSuite) TestStreamBody() {
+	suite.Run("streams readers of known length", func() {
+		doer := &RecordingHTTPClient{}
+		_, err := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodPut).
+			Body(strings.NewReader("payload")).
+			Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Equal("payload", doer.Bodies[0])
+		suite.Equal(int64(len("payload")), doer.Requests[0].ContentLength)
+		suite.Equal(MIMEOctetStream, doer.Requests[0].Header.Get(HeaderContentType))
+	})
+
+	suite.Run("streams readers of unknown length", func() {
+		doer := &RecordingHTTPClient{}
+		_, err := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodPut).
+			Headers(http.Header{HeaderContentType: []string{MIMETextPlain}}).
+			Body(io.NopCloser(strings.NewReader("payload"))).
+			Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Equal("payload", doer.Bodies[0])
+		suite.Equal(int64(-1), doer.Requests[0].ContentLength)
+		suite.Equal(MIMETextPlain, doer.Requests[0].Header.Get(HeaderContentType))
+	})
+
+	suite.Run("uses the explicit encoder for readers", func() {
+		doer := &RecordingHTTPClient{}
+		_, err := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodPut).
+			Encoder(&RawRequestEncoder{}).
+			Body(io.MultiReader(strings.NewReader("payload"))).
+			Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Equal("payload", doer.Bodies[0])
+		suite.Equal(int64(len("payload")), doer.Requests[0].ContentLength)
+	})
+
+	suite.Run("compresses streamed bodies with gzip", func() {
+		doer := &RecordingHTTPClient{}
+		_, err := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodPut).
+			Body(strings.NewReader("payload")).
+			UseGzipCompress(true).
+			Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Equal("gzip", doer.Requests[0].Header.Get("Content-Encoding"))
+		suite.Equal(int64(-1), doer.Requests[0].ContentLength)
+
+		gz, err := gzip.NewReader(strings.NewReader(doer.Bodies[0]))
+		require.NoError(suite.T(), err)
+		data, err := io.ReadAll(gz)
+		require.NoError(suite.T(), err)
+		suite.Equal("payload", string(data))
+	})
+}
+
+func (suite *HTTPClientCallUploadSuite) TestStreamBody_Retries() {
+	suite.Run("rewinds seekable readers", func() {
+		doer := suite.retryingDoer()
+		_, err := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodPut).
+			Body(bytes.NewReader([]byte("payload"))).
+			Retry(suite.policy).
+			Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Equal([]string{"payload", "payload"}, doer.Bodies)
+	})
+
+	suite.Run("uses the GetBody factory", func() {
+		doer := suite.retryingDoer()
+		getBody := func() (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader("payload")), nil
+		}
+		_, err := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodPut).
+			GetBody(getBody).
+			Retry(suite.policy).
+			Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Equal([]string{"payload", "payload"}, doer.Bodies)
+	})
+
+	suite.Run("does not retry bodies that cannot be replayed", func() {
+		doer := suite.retryingDoer()
+		resp, err := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodPut).
+			Body(io.NopCloser(strings.NewReader("payload"))).
+			Retry(suite.policy).
+			Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Equal(http.StatusServiceUnavailable, resp.StatusCode)
+		suite.Equal(1, doer.Calls)
+	})
+
+	suite.Run("returns GetBody errors", func() {
+		_, err := NewHTTPClientCall("http://example.com", &RecordingHTTPClient{}).
+			Method(http.MethodPut).
+			GetBody(func() (io.ReadCloser, error) { return nil, errors.New("open failed") }).
+			Do(context.Background())
+		suite.EqualError(err, "open failed")
+	})
+}
+
+func (suite *HTTPClientCallUploadSuite) TestUploadProgress() {
+	suite.Run("reports the bytes sent and the total size", func() {
+		var sent, total []int64
+		doer := &RecordingHTTPClient{}
+		_, err := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodPost).
+			Body(map[string]string{"key": "value"}).
+			OnUploadProgress(func(s, t int64) {
+				sent = append(sent, s)
+				total = append(total, t)
+			}).
+			Do(context.Background())
+		require.NoError(suite.T(), err)
+		require.NotEmpty(suite.T(), sent)
+		expected := int64(len(doer.Bodies[0]))
+		suite.Equal(expected, sent[len(sent)-1])
+		suite.Equal(expected, total[0])
+	})
+
+	suite.Run("reports an unknown total size and restarts on retries", func() {
+		var sent, total []int64
+		doer := suite.retryingDoer()
+		_, err := NewHTTPClientCall("http://example.com", doer).
+			Method(http.MethodPut).
+			Body(io.MultiReader(strings.NewReader("payload"))).
+			GetBody(func() (io.ReadCloser, error) { return io.NopCloser(strings.NewReader("payload")), nil }).
+			OnUploadProgress(func(s, t int64) {
+				sent = append(sent, s)
+				total = append(total, t)
+			}).
+			Retry(suite.policy).
+			Do(context.Background())
+		require.NoError(suite.T(), err)
+		suite.Equal([]int64{7, 7}, sent)
+		suite.Equal([]int64{-1, -1}, total)
+	})
+}
+
+func TestHTTPClientCallUploadSuite(t *testing.T) {
+	suite.Run(t, new(HTTPClientCallUploadSuite))
+}
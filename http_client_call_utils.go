@@ -2,9 +2,7 @@ package client
 
 import (
 	"bytes"
-	"compress/gzip"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -41,38 +39,81 @@ func (r *HTTPClientCall) constructURLPath() string {
 	return pathWithParams
 }
 
-// setRequestBody sets the body for the HTTP request. If gzipCompress is true, the body is gzip compressed.
+// setRequestBody sets the body for the HTTP request and tracks its upload progress.
+// Multipart forms and io.Reader bodies are streamed; any other body is encoded with the selected encoder.
 func (r *HTTPClientCall) setRequestBody(req *http.Request) error {
-	if r.body == nil {
+	if r.body == nil && r.getBody == nil {
 		req.ContentLength = 0
 		return nil
 	}
 
-	var serializedBody bytes.Buffer
-	if err := json.NewEncoder(&serializedBody).Encode(r.body); err != nil {
-		return err
-	}
-
-	if r.gzipCompress {
-		var buf bytes.Buffer
-		gz := gzip.NewWriter(&buf)
-		if _, err := gz.Write(serializedBody.Bytes()); err != nil {
+	switch body := r.body.(type) {
+	case nil:
+		stream, err := r.getBody()
+		if err != nil {
 			return err
 		}
-		if err := gz.Close(); err != nil {
+		r.setStreamBody(req, stream)
+	case *MultipartForm:
+		r.setMultipartBody(req, body)
+	case io.Reader:
+		if r.encoder != nil {
+			return r.setEncodedBody(req)
+		}
+		r.setStreamBody(req, body)
+	default:
+		if err := r.setEncodedBody(req); err != nil {
 			return err
 		}
-		req.Header.Set("Content-Encoding", "gzip")
-		req.Body = io.NopCloser(&buf)
-		req.ContentLength = int64(buf.Len())
-	} else {
-		req.Body = io.NopCloser(&serializedBody)
-		req.ContentLength = int64(serializedBody.Len())
 	}
 
+	r.trackUploadProgress(req)
+	return nil
+}
+
+// setEncodedBody encodes the body for the HTTP request with the selected encoder. The body is compressed when request
+// compression is enabled and the encoded body reaches the minimum size.
+func (r *HTTPClientCall) setEncodedBody(req *http.Request) error {
+	encoder := r.selectEncoder()
+	var serializedBody bytes.Buffer
+	if err := encoder.Encode(&serializedBody, r.body); err != nil {
+		return err
+	}
+	if r.headerValue(HeaderContentType) == "" {
+		req.Header.Set(HeaderContentType, encoder.ContentType())
+	}
+
+	compression := r.requestCompression()
+	if compression == nil || serializedBody.Len() < compression.MinSize {
+		setReplayableBody(req, serializedBody.Bytes())
+		return nil
+	}
+
+	var buf bytes.Buffer
+	w, err := compression.Compressor.NewWriter(&buf, compression.Level)
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(serializedBody.Bytes()); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	req.Header.Set(HeaderContentEncoding, compression.Compressor.Encoding())
+	setReplayableBody(req, buf.Bytes())
 	return nil
 }
 
+// setReplayableBody sets data as the request body and lets it be re-created for every retry attempt.
+func setReplayableBody(req *http.Request, data []byte) {
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.ContentLength = int64(len(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+}
+
 // EncodeWithoutScapes encodes the URL values without escaping special characters.
 func EncodeWithoutScapes(v url.Values) string {
 	if v == nil {
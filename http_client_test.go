@@ -0,0 +1,156 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type RecordingHTTPClient struct {
+	mu       sync.Mutex
+	Requests []*http.Request
+	Bodies   []string
+}
+
+func (m *RecordingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	var body string
+	if req.Body != nil {
+		data, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		body = string(data)
+	}
+	m.mu.Lock()
+	m.Requests = append(m.Requests, req)
+	m.Bodies = append(m.Bodies, body)
+	m.mu.Unlock()
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{HeaderContentType: []string{MIMEApplicationJSON}},
+		Body:       io.NopCloser(bytes.NewBufferString(body)),
+	}, nil
+}
+
+type HTTPClientSuite struct {
+	suite.Suite
+	doer *RecordingHTTPClient
+	host string
+}
+
+func (suite *HTTPClientSuite) SetupTest() {
+	suite.doer = &RecordingHTTPClient{}
+	suite.host = "http://example.com"
+}
+
+func (suite *HTTPClientSuite) TestNewHTTPClient() {
+	suite.Run("creates new HTTPClient with options", func() {
+		headers := http.Header{HeaderAuthorization: []string{"Bearer token"}}
+		policy := NewRetryPolicy()
+		c := NewHTTPClient(suite.host, suite.doer,
+			WithDefaultHeaders(headers),
+			WithRetryPolicy(policy),
+			WithEncodeURL(false),
+		)
+		suite.Equal(suite.host, c.host)
+		suite.Equal(suite.doer, c.doer)
+		suite.Equal(headers, c.headers)
+		suite.Equal(policy, c.retryPolicy)
+		suite.False(c.isEncodeURL)
+
+		headers.Set(HeaderAuthorization, "changed")
+		suite.Equal("Bearer token", c.headers.Get(HeaderAuthorization))
+	})
+
+	suite.Run("panics on nil client", func() {
+		suite.PanicsWithValue("You must create client", func() {
+			NewHTTPClient(suite.host, nil)
+		})
+	})
+
+	suite.Run("panics on empty host", func() {
+		suite.PanicsWithValue("empty host", func() {
+			NewHTTPClient("", suite.doer)
+		})
+	})
+}
+
+func (suite *HTTPClientSuite) TestNewCall() {
+	suite.Run("inherits the client configuration", func() {
+		policy := NewRetryPolicy()
+		c := NewHTTPClient(suite.host, suite.doer, WithRetryPolicy(policy), WithEncodeURL(false))
+
+		call := c.NewCall()
+		suite.Equal(suite.host, call.host)
+		suite.Equal(suite.doer, call.client)
+		suite.Equal(policy, call.retryPolicy)
+		suite.False(call.isEncodeURL)
+		suite.NotSame(call, c.NewCall())
+	})
+
+	suite.Run("request headers take precedence over default headers", func() {
+		c := NewHTTPClient(suite.host, suite.doer, WithDefaultHeaders(http.Header{
+			HeaderAuthorization: []string{"Bearer default"},
+			HeaderXRequestID:    []string{"default-id"},
+		}))
+
+		_, err := c.NewCall().
+			Method(http.MethodGet).
+			Headers(http.Header{HeaderXRequestID: []string{"request-id"}}).
+			Do(context.Background())
+		require.NoError(suite.T(), err)
+
+		req := suite.doer.Requests[0]
+		suite.Equal("Bearer default", req.Header.Get(HeaderAuthorization))
+		suite.Equal([]string{"request-id"}, req.Header.Values(HeaderXRequestID))
+	})
+}
+
+func (suite *HTTPClientSuite) TestConcurrentCalls() {
+	suite.Run("concurrent calls do not interfere", func() {
+		c := NewHTTPClient(suite.host, suite.doer, WithDefaultHeaders(http.Header{
+			HeaderAccept: []string{MIMEApplicationJSON},
+		}))
+
+		const calls = 50
+		var wg sync.WaitGroup
+		for i := range calls {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				id := fmt.Sprint(i)
+				var out map[string]string
+				_, err := c.NewCall().
+					Method(http.MethodPost).
+					Path("/items/"+id).
+					Params(url.Values{"id": []string{id}}).
+					Headers(http.Header{HeaderXRequestID: []string{id}}).
+					Body(map[string]string{"id": id}).
+					DoWithUnmarshal(context.Background(), &out)
+				suite.NoError(err)
+				suite.Equal(id, out["id"])
+			}()
+		}
+		wg.Wait()
+
+		suite.Len(suite.doer.Requests, calls)
+		for i, req := range suite.doer.Requests {
+			id := req.Header.Get(HeaderXRequestID)
+			suite.Equal("/items/"+id, req.URL.Path)
+			suite.Equal(id, req.URL.Query().Get("id"))
+			suite.JSONEq(fmt.Sprintf(`{"id":%q}`, id), suite.doer.Bodies[i])
+		}
+	})
+}
+
+func TestHTTPClientSuite(t *testing.T) {
+	suite.Run(t, new(HTTPClientSuite))
+}
@@ -1,9 +1,18 @@
 package client
 
+import (
+	"errors"
+	"mime"
+)
+
 // MIME types
 const (
 	MIMEApplicationJSON                  = "application/json"
 	MIMEApplicationJSONCharsetUTF8       = MIMEApplicationJSON + "; " + charsetUTF8
+	MIMEApplicationProblemJSON           = "application/problem+json"
+	MIMEApplicationNDJSON                = "application/x-ndjson"
+	MIMEApplicationJSONLines             = "application/jsonl"
+	MIMEApplicationXJSONLines            = "application/x-jsonlines"
 	MIMEApplicationJavaScript            = "application/javascript"
 	MIMEApplicationJavaScriptCharsetUTF8 = MIMEApplicationJavaScript + "; " + charsetUTF8
 	MIMEApplicationXML                   = "application/xml"
@@ -18,7 +27,10 @@ const (
 	MIMETextPlain                        = "text/plain"
 	MIMETextPlainCharsetUTF8             = MIMETextPlain + "; " + charsetUTF8
 	MIMEMultipartForm                    = "multipart/form-data"
+	MIMETextEventStream                  = "text/event-stream"
 	MIMEOctetStream                      = "application/octet-stream"
+	MIMEApplicationPDF                   = "application/pdf"
+	MIMEApplicationZip                   = "application/zip"
 )
 
 const (
@@ -27,4 +39,26 @@ const (
 	PROPFIND = "PROPFIND"
 	// REPORT Method can be used to get information about a resource, see rfc 3253
 	REPORT = "REPORT"
+	// PROPPATCH Method sets and removes properties of a resource, see rfc 4918
+	PROPPATCH = "PROPPATCH"
+	// MKCOL Method creates a new collection resource, see rfc 4918
+	MKCOL = "MKCOL"
+	// COPY Method creates a duplicate of a resource, see rfc 4918
+	COPY = "COPY"
+	// MOVE Method moves a resource to the destination URI, see rfc 4918
+	MOVE = "MOVE"
+	// LOCK Method takes out a lock on a resource, see rfc 4918
+	LOCK = "LOCK"
+	// UNLOCK Method removes a lock from a resource, see rfc 4918
+	UNLOCK = "UNLOCK"
 )
+
+// parseMediaType returns the lowercase media type of a Content-Type. Unlike mime.ParseMediaType, it keeps the media
+// type when only the parameters are invalid, such as a charset without a value.
+func parseMediaType(contentType string) (string, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil && !errors.Is(err, mime.ErrInvalidMediaParameter) {
+		return "", false
+	}
+	return mediaType, true
+}